@@ -0,0 +1,81 @@
+/*
+Copyright © 2023 Jose Cueto
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+package cmd
+
+import (
+	"os"
+
+	logger "github.com/sirupsen/logrus"
+	"github.com/spf13/cobra"
+
+	pkgInt "ocm-workspace/internal"
+	pkgIntHelper "ocm-workspace/internal/helpers"
+)
+
+var attachCmd = &cobra.Command{
+	Use:    "attach [cluster or container]",
+	Short:  "Opens a new shell in a running workspace container.",
+	Long:   `Opens a new shell in a running workspace container given its cluster, container name or ID. If there is only one running workspace, the argument can be omitted.`,
+	Args:   cobra.MaximumNArgs(1),
+	PreRun: toggleDebug,
+	Run:    onAttach,
+}
+
+func onAttach(cmd *cobra.Command, args []string) {
+	ce := newContainerEngine()
+	workspaces, err := pkgInt.ListWorkspaces(ce, false)
+	if err != nil {
+		logger.Fatal("Failed to list workspaces: ", err)
+	}
+
+	var ref string
+	if len(args) > 0 {
+		ref = args[0]
+	}
+	ws, err := pkgInt.FindWorkspace(workspaces, ref)
+	if err != nil {
+		logger.Fatal(err)
+	}
+
+	err = attachWorkspace(ce, ws)
+	if err != nil {
+		logger.Fatal("Failed to attach to workspace: ", err)
+	}
+}
+
+// Runs an interactive shell as the host user inside a running workspace.
+func attachWorkspace(ce pkgInt.ContainerEngine, ws *pkgInt.Workspace) error {
+	hostUser := ws.HostUser
+	if len(hostUser) == 0 {
+		hostUser = config.HostUser
+	}
+
+	logger.Infof("Attaching to workspace %s (cluster: %s)", ws.Name, ws.Cluster)
+	execArgs := ce.GetExecArgs(ws.Name, "", true, "sudo", "-Eu", hostUser, "bash")
+	logger.Debugf("Container exec command: %v", execArgs)
+
+	return pkgIntHelper.RunCommandWithOsFiles(
+		ce.GetExecName(),
+		os.Stdout,
+		os.Stderr,
+		os.Stdin,
+		execArgs...,
+	)
+}
+
+func init() {
+	rootCmd.AddCommand(attachCmd)
+}
@@ -18,8 +18,6 @@ package cmd
 import (
 	pkgIntHelper "ocm-workspace/internal/helpers"
 
-	pkgInt "ocm-workspace/internal"
-
 	logger "github.com/sirupsen/logrus"
 	"github.com/spf13/cobra"
 )
@@ -29,19 +27,21 @@ var buildCmd = &cobra.Command{
 	Short: "Builds the OCM workspace image locally.",
 	Long:  `Builds the OCM workspace image locally and tags it with ocm-workspace:latest:`,
 	Run: func(cmd *cobra.Command, args []string) {
+		ce := newContainerEngine()
 
-		ceFactory := pkgInt.NewCeFactory(map[string]interface{}{
-			"ceName": "podman",
-		})
-
-		ce, err := ceFactory.Create()
-		if err != nil {
-			logger.Fatal("Failed to create container engine: ", err)
+		// Unset values fall back to the Dockerfile defaults
+		for _, buildArg := range [][]string{
+			{"BASE_IMAGE", config.BaseImage},
+			{"OCM_CLI_VERSION", config.OCMCLIVersion},
+			{"BACKPLANE_CLI_VERSION", config.BackplaneCLIVersion},
+		} {
+			if len(buildArg[1]) > 0 {
+				ce.AppendBuildArg(buildArg[0], buildArg[1])
+			}
+		}
+		for _, env := range config.GetProxy("").GetEnvVars() {
+			ce.AppendBuildArg(env[0], env[1])
 		}
-
-		ce.AppendBuildArg("BASE_IMAGE", config.BaseImage)
-		ce.AppendBuildArg("OCM_CLI_VERSION", config.OCMCLIVersion)
-		ce.AppendBuildArg("BACKPLANE_CLI_VERSION", config.BackplaneCLIVersion)
 
 		out, err := pkgIntHelper.RunCommandOutput(
 			"git",
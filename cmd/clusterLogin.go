@@ -18,9 +18,7 @@ package cmd
 import (
 	"fmt"
 	"os"
-	"path/filepath"
 	"strconv"
-	"strings"
 
 	pkgInt "ocm-workspace/internal"
 	pkgIntHelper "ocm-workspace/internal/helpers"
@@ -48,30 +46,15 @@ func onClusterLogin(cmd *cobra.Command, args []string) {
 
 	configureOCMUser()
 	configureWorkspaceDirs()
-	OCMLogin()
-	OCMBackplaneLogin()
-
-	customPortMapsStr := strings.Trim(getEnvVar("CUSTOM_PORT_MAPS"), ",")
-	var allocatedContainerPorts []string
-
-	for _, pm := range strings.Split(customPortMapsStr, ",") {
-		ports := strings.Split(pm, ":")
-		allocatedContainerPorts = append(allocatedContainerPorts, ports[1])
-	}
-
-	plugins := config.Plugins
-	for _, plug := range plugins {
+	checkPluginPorts()
 
-		if plug.RunOn == "ocmBackplaneLoginSuccess" {
-			err := OCMBackplaneLoginSuccess(plug, allocatedContainerPorts)
-			if err != nil {
-				logger.Fatalf("Plugin %v failed to run: %v", plug.Name, err)
-			}
-		}
+	OCMLogin()
+	runContainerHooks(pkgInt.HookOcmLoginSuccess, nil)
 
-		if (len(allocatedContainerPorts) - plug.AllocatePorts) > 1 {
-			allocatedContainerPorts = allocatedContainerPorts[plug.AllocatePorts+1:]
-		}
+	OCMBackplaneLogin()
+	if isOcmLoginOnly, _ := strconv.ParseBool(ocmWorkspace.IsOcmLoginOnly); !isOcmLoginOnly {
+		setDefaultNamespace()
+		runContainerHooks(pkgInt.HookBackplaneLoginSuccess, nil)
 	}
 
 	runTerminal()
@@ -116,47 +99,34 @@ func runTerminal() {
 				logger.Errorf("Failed to write to file %s: %s\n", ocmWorkspace.UserBashrcPath, err)
 			}
 		}
+
+		_, err = file.WriteString(getShellHooks())
+		if err != nil {
+			logger.Errorf("Failed to write to file %s: %s\n", ocmWorkspace.UserBashrcPath, err)
+		}
 	}
 	err = pkgIntHelper.RunCommandWithOsFiles("sudo", os.Stdout, os.Stderr, os.Stdin, "-Eu", ocmWorkspace.HostUser, "bash")
+
+	// The plugins do not outlive the login shell
+	stopPlugins()
 	if err != nil {
 		logger.Fatal("Failed to run command: ", err)
 	}
 }
 
-func runPlugin(plug pkgInt.Plugin, configPath string, envVars [][]string) error {
-	executable := filepath.Base(plug.ExecPath)
-	cmdArgs := []string{
-		"-Eu",
-		getEnvVar("HOST_USER"),
-		fmt.Sprintf("/usr/bin/%s", executable),
-		plug.ExecCommand,
-		"--config", configPath}
-	if debug {
-		cmdArgs = append(cmdArgs, "-d")
-	}
-	logger.Debugf("Running plugin with args: %v %v", cmdArgs, envVars)
-
-	err := pkgIntHelper.RunCommandBackground("sudo", cmdArgs, envVars)
-	return err
-}
-
-func OCMBackplaneLoginSuccess(plug pkgInt.Plugin, allocatedContainerPorts []string) error {
-
-	// Create (overwrite) plugin config
-	configPath := fmt.Sprintf("%s/.%s.yaml", ocmWorkspace.UserHome, plug.Name)
-	createConfigFile(configPath, plug.Config)
-
-	if plug.AllocatePorts > len(allocatedContainerPorts) {
-		logger.Fatalf("Not enough custom port maps for plugin use.")
-	}
-
-	// Pass allocated container ports to plugin as environment variables
-	envVars := [][]string{
-		{"PLUGIN_SERVICE", getEnvVar("PLUGIN_SERVICE")},
-		{"HOST_USER", getEnvVar("HOST_USER")},
+// Checks that the plugins got the ports they allocate.
+func checkPluginPorts() {
+	pluginPorts := pkgInt.ParsePluginPorts(ocmWorkspace.PluginPortMaps)
+	for _, plug := range config.Plugins {
+		if len(pluginPorts[plug.Name]) != plug.AllocatePorts {
+			logger.Fatalf(
+				"Plugin %s allocates %d ports but got %d.",
+				plug.Name,
+				plug.AllocatePorts,
+				len(pluginPorts[plug.Name]),
+			)
+		}
 	}
-
-	return runPlugin(plug, configPath, envVars)
 }
 
 func OCMBackplaneLogin() {
@@ -185,21 +155,45 @@ func OCMBackplaneLogin() {
 
 }
 
-func OCMLogin() {
-	logger.Info("Logging into ocm ", ocmWorkspace.OcmEnvironment)
+// Switches to the configured default namespace after the backplane login.
+func setDefaultNamespace() {
+	if len(config.DefaultNamespace) == 0 {
+		return
+	}
 
 	status := pkgIntHelper.RunCommandStreamOutput(
 		"sudo",
 		"-Eu",
 		ocmWorkspace.HostUser,
-		"ocm",
-		"login",
-		fmt.Sprintf("--token=%s", ocmWorkspace.OcmToken),
-		fmt.Sprintf("--url=%s", ocmWorkspace.OcmEnvironment),
+		"oc",
+		"project",
+		config.DefaultNamespace,
 	)
-
 	if status.Exit != 0 {
-		logger.Fatalf("OCM Login failed: %v", status.Error)
+		logger.Warnf("Failed to switch to the default namespace %s: %v", config.DefaultNamespace, status.Error)
+	}
+}
+
+// Logs in to OCM. The token is read from the mounted token file and passed
+// to ocm login in its environment only, never on a command line where it
+// would be visible in the process list of the host.
+func OCMLogin() {
+	logger.Info("Logging into ocm ", ocmWorkspace.OcmEnvironment)
+
+	ocmToken := readOcmTokenFile(getEnvVar("OCM_TOKEN_FILE"))
+	err := pkgIntHelper.RunCommandWithEnv(
+		"sudo",
+		[]string{
+			"-Eu",
+			ocmWorkspace.HostUser,
+			"ocm",
+			"login",
+			fmt.Sprintf("--url=%s", ocmWorkspace.OcmUrl),
+		},
+		[][]string{{"OCM_TOKEN", ocmToken}},
+	)
+	if err != nil {
+		logger.Fatalf("OCM Login failed: %v", err)
 	}
 
 	logger.Info("OCM Login successful.")
@@ -17,46 +17,28 @@ limitations under the License.
 package cmd
 
 import (
+	"encoding/json"
 	"errors"
 	"fmt"
 	"os"
 	"strings"
 
 	logger "github.com/sirupsen/logrus"
+	"gopkg.in/yaml.v3"
 
 	pkgInt "ocm-workspace/internal"
 )
 
-type containerEngine interface {
-	appendEnvVar(key string, value string)
-	getEnvVars() [][]string
-	appendVolMap(hostVol string, containerVol string, mapAttrs string)
-	getVolMaps() [][]string
-	appendPortMap(hostPort string, containerPort string, hostAddr string)
-	getPortMaps() [][]string
-	toEnvVarArgs() []string
-	toVolMapArgs() []string
-	toPortMapArgs() []string
-	getRunArgs(containerName string, entryPoint string, image string, entryPointArgs ...string) []string
-	getExecName() string
-	appendBuildArg(name string, value string)
-	toBuildArgs() []string
-	getBuildArgs() []string
-}
-
-type containerEngineFactory interface {
-	create() (containerEngine, error)
-}
-
 type ocmWorkspaceContainer struct {
 	HostUser         string
 	UserHome         string
 	IsOcmLoginOnly   string
 	CUSTOM_PORT_MAPS string
+	PluginPortMaps   string
 	UserBashrcPath   string
 	OcmCluster       string
-	OcmToken         string
 	OcmEnvironment   string
+	OcmUrl           string
 }
 
 func NewOcmWorkspaceContainer(config *pkgInt.OcmWorkspaceConfig) *ocmWorkspaceContainer {
@@ -68,13 +50,68 @@ func NewOcmWorkspaceContainer(config *pkgInt.OcmWorkspaceConfig) *ocmWorkspaceCo
 		UserHome:         config.UserHome,
 		IsOcmLoginOnly:   getEnvVar("IS_OCM_LOGIN_ONLY"),
 		CUSTOM_PORT_MAPS: getEnvVar("CUSTOM_PORT_MAPS"),
+		PluginPortMaps:   getEnvVar("PLUGIN_PORT_MAPS"),
 		UserBashrcPath:   fmt.Sprintf("%s/.bashrc", config.UserHome),
 		OcmCluster:       getEnvVar("OCM_CLUSTER"),
-		OcmToken:         getEnvVar("OCM_TOKEN"),
 		OcmEnvironment:   getEnvVar("OCM_ENVIRONMENT"),
+		OcmUrl:           getEnvVar("OCM_URL"),
 	}
 }
 
+// Creates the container engine selected by the containerEngine config key
+// or the --engine flag.
+func newContainerEngine() pkgInt.ContainerEngine {
+	ceFactory := pkgInt.NewCeFactory(map[string]interface{}{
+		"ceName": config.GetContainerEngine(),
+	})
+
+	ce, err := ceFactory.Create()
+	if err != nil {
+		logger.Fatal("Failed to create container engine: ", err)
+	}
+	return ce
+}
+
+// Resolves workspace references (cluster, container name or ID) to
+// workspaces. If selectAll is true, all of the listed workspaces are returned.
+func resolveWorkspaces(ce pkgInt.ContainerEngine, refs []string, selectAll bool, includeStopped bool) []pkgInt.Workspace {
+	workspaces, err := pkgInt.ListWorkspaces(ce, includeStopped)
+	if err != nil {
+		logger.Fatal("Failed to list workspaces: ", err)
+	}
+
+	if selectAll {
+		return workspaces
+	}
+
+	if len(refs) == 0 {
+		refs = []string{""}
+	}
+
+	resolved := []pkgInt.Workspace{}
+	for _, ref := range refs {
+		ws, err := pkgInt.FindWorkspace(workspaces, ref)
+		if err != nil {
+			logger.Fatal(err)
+		}
+		resolved = append(resolved, *ws)
+	}
+	return resolved
+}
+
+// Reads the OCM token from the file mounted in the workspace container.
+func readOcmTokenFile(path string) string {
+	if len(path) == 0 {
+		logger.Fatal("OCM_TOKEN_FILE is not set")
+	}
+
+	content, err := os.ReadFile(path)
+	if err != nil {
+		logger.Fatalf("Failed to read the OCM token file %s: %v", path, err)
+	}
+	return strings.TrimSpace(string(content))
+}
+
 func checkContainerCommand() error {
 	if !isInContainer() {
 		return errors.New("this command is intended to be run only inside the workspace container")
@@ -98,3 +135,24 @@ func createConfigFile(path string, content string) {
 	defer fayl.Close()
 	fayl.WriteString(content)
 }
+
+// Prints a value in a structured output format (json or yaml).
+func printStructured(format string, value interface{}) error {
+	switch format {
+	case "json":
+		out, err := json.MarshalIndent(value, "", "  ")
+		if err != nil {
+			return err
+		}
+		fmt.Println(string(out))
+	case "yaml":
+		out, err := yaml.Marshal(value)
+		if err != nil {
+			return err
+		}
+		fmt.Print(string(out))
+	default:
+		return fmt.Errorf("unsupported output format %q (supported: json, yaml)", format)
+	}
+	return nil
+}
@@ -0,0 +1,321 @@
+/*
+Copyright © 2023 Jose Cueto
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+package cmd
+
+import (
+	"bufio"
+	"bytes"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+
+	logger "github.com/sirupsen/logrus"
+	"github.com/spf13/cobra"
+	"github.com/spf13/viper"
+	"gopkg.in/yaml.v3"
+
+	pkgInt "ocm-workspace/internal"
+)
+
+var (
+	configInitCmdArgs struct {
+		nonInteractive        bool
+		force                 bool
+		hostUser              string
+		ocUser                string
+		userHome              string
+		backplaneConfigProd   string
+		backplaneConfigStage  string
+		ocmLongLivedTokenPath string
+		containerEngine       string
+		baseImage             string
+		ocmCLIVersion         string
+		backplaneCLIVersion   string
+	}
+)
+
+// generatedConfig holds the keys written by config init, in file order.
+type generatedConfig struct {
+	OcUser                string `yaml:"ocUser"`
+	BackplaneConfigProd   string `yaml:"backplaneConfigProd,omitempty"`
+	BackplaneConfigStage  string `yaml:"backplaneConfigStage,omitempty"`
+	OcmLongLivedTokenPath string `yaml:"ocmLongLivedTokenPath,omitempty"`
+	HostUser              string `yaml:"hostUser"`
+	UserHome              string `yaml:"userHome"`
+	ContainerEngine       string `yaml:"containerEngine"`
+	BaseImage             string `yaml:"baseImage,omitempty"`
+	OCMCLIVersion         string `yaml:"ocmCLIVersion,omitempty"`
+	BackplaneCLIVersion   string `yaml:"backplaneCLIVersion,omitempty"`
+}
+
+var configInitCmd = &cobra.Command{
+	Use:   "init",
+	Short: "Creates the config file.",
+	Long: `Creates the config file from the detected host user, home directory, backplane configs,
+container engine and OCM CLI, asking for the remaining values. With --non-interactive the
+values are taken from the flags and the detected defaults only.`,
+	Args:        cobra.NoArgs,
+	Annotations: map[string]string{skipConfigAnnotation: "true"},
+	PreRun:      toggleDebug,
+	Run:         onConfigInit,
+}
+
+func onConfigInit(cmd *cobra.Command, args []string) {
+	path := cfgFile
+	if len(path) == 0 {
+		home, err := os.UserHomeDir()
+		if err != nil {
+			logger.Fatal(err)
+		}
+		path = filepath.Join(home, ".ocm-workspace.yaml")
+	}
+
+	if _, err := os.Stat(path); err == nil && !configInitCmdArgs.force {
+		logger.Fatalf("Config file %s already exists, use --force to overwrite it", path)
+	}
+
+	prompter := &configPrompter{
+		reader:         bufio.NewReader(os.Stdin),
+		nonInteractive: configInitCmdArgs.nonInteractive,
+	}
+
+	conf, err := promptConfig(cmd, prompter)
+	if err != nil {
+		logger.Fatal(err)
+	}
+
+	content, err := yaml.Marshal(conf)
+	if err != nil {
+		logger.Fatal("Failed to marshal config: ", err)
+	}
+
+	// Validate the config as the workspace commands will load it
+	loaded, err := loadConfigContent(content)
+	if err != nil {
+		logger.Fatal("Failed to load the generated config: ", err)
+	}
+	if errs := loaded.Validate(); len(errs) > 0 {
+		for _, err := range errs {
+			logger.Error(err)
+		}
+		logger.Fatal("Invalid config, the config file is not written")
+	}
+
+	err = os.WriteFile(path, content, 0600)
+	if err != nil {
+		logger.Fatal("Failed to write config file: ", err)
+	}
+	logger.Infof("Created config file %s", path)
+}
+
+// Gathers the config values from the flags, the detected defaults and the
+// prompts. Values set by flags are not prompted for.
+func promptConfig(cmd *cobra.Command, p *configPrompter) (*generatedConfig, error) {
+	flags := cmd.Flags()
+	args := configInitCmdArgs
+
+	conf := &generatedConfig{}
+	var err error
+	// Defaults are evaluated in order, after the previous answers
+	prompts := []struct {
+		flag     string
+		question string
+		value    *string
+		def      func() string
+		required bool
+	}{
+		{"hostUser", "Host user name", &conf.HostUser, func() string {
+			return orDefault(args.hostUser, os.Getenv("USER"))
+		}, true},
+		{"userHome", "Host user home directory", &conf.UserHome, func() string {
+			return orDefault(args.userHome, os.Getenv("HOME"))
+		}, true},
+		{"ocUser", "OpenShift CLI user", &conf.OcUser, func() string {
+			return orDefault(args.ocUser, conf.HostUser)
+		}, true},
+		{"backplaneConfigProd", "Backplane config of OCM production (in ~/.config/backplane)", &conf.BackplaneConfigProd, func() string {
+			prodConfig, _ := detectBackplaneConfigs(conf.UserHome)
+			return orDefault(args.backplaneConfigProd, prodConfig)
+		}, false},
+		{"backplaneConfigStage", "Backplane config of OCM staging (in ~/.config/backplane)", &conf.BackplaneConfigStage, func() string {
+			_, stageConfig := detectBackplaneConfigs(conf.UserHome)
+			return orDefault(args.backplaneConfigStage, stageConfig)
+		}, false},
+		{"ocmLongLivedTokenPath", "OCM offline token file (empty to use 'ocm token')", &conf.OcmLongLivedTokenPath, func() string {
+			return args.ocmLongLivedTokenPath
+		}, false},
+		{"containerEngine", "Container engine", &conf.ContainerEngine, func() string {
+			return orDefault(args.containerEngine, detectContainerEngine())
+		}, true},
+		{"baseImage", "Base image of the workspace image (empty for the Dockerfile default)", &conf.BaseImage, func() string {
+			return args.baseImage
+		}, false},
+		{"ocmCLIVersion", "OCM CLI version (empty for the Dockerfile default)", &conf.OCMCLIVersion, func() string {
+			return args.ocmCLIVersion
+		}, false},
+		{"backplaneCLIVersion", "Backplane CLI version (empty for the Dockerfile default)", &conf.BackplaneCLIVersion, func() string {
+			return args.backplaneCLIVersion
+		}, false},
+	}
+
+	for _, prompt := range prompts {
+		*prompt.value = prompt.def()
+		if !flags.Changed(prompt.flag) {
+			*prompt.value, err = p.ask(prompt.question, *prompt.value)
+			if err != nil {
+				return nil, err
+			}
+		}
+		if prompt.required && len(*prompt.value) == 0 {
+			return nil, fmt.Errorf("%s is required (--%s)", prompt.flag, prompt.flag)
+		}
+	}
+
+	if len(conf.OcmLongLivedTokenPath) == 0 {
+		if _, err := exec.LookPath("ocm"); err != nil {
+			return nil, errors.New("the ocm CLI is not installed, set the OCM offline token file (--ocmLongLivedTokenPath)")
+		}
+	}
+	return conf, nil
+}
+
+// Loads config file content the way the workspace commands do.
+func loadConfigContent(content []byte) (*pkgInt.OcmWorkspaceConfig, error) {
+	v := viper.New()
+	v.SetConfigType("yaml")
+	err := v.ReadConfig(bytes.NewReader(content))
+	if err != nil {
+		return nil, err
+	}
+
+	var conf pkgInt.OcmWorkspaceConfig
+	err = v.Unmarshal(&conf)
+	if err != nil {
+		return nil, err
+	}
+	return &conf, nil
+}
+
+// Finds the production and staging backplane configs in
+// ~/.config/backplane (e.g. config.prod.json and config.stage.json).
+func detectBackplaneConfigs(userHome string) (string, string) {
+	var prodConfig, stageConfig string
+	matches, _ := filepath.Glob(filepath.Join(userHome, ".config", "backplane", "config.*.json"))
+	for _, match := range matches {
+		name := filepath.Base(match)
+		switch {
+		case strings.Contains(name, "prod") && len(prodConfig) == 0:
+			prodConfig = name
+		case strings.Contains(name, "stag") && len(stageConfig) == 0:
+			stageConfig = name
+		}
+	}
+	return prodConfig, stageConfig
+}
+
+// Finds the first installed container engine, defaults to podman.
+func detectContainerEngine() string {
+	for _, name := range []string{"podman", "docker", "nerdctl"} {
+		if _, err := exec.LookPath(name); err == nil {
+			return name
+		}
+	}
+	return "podman"
+}
+
+func orDefault(value string, def string) string {
+	if len(value) > 0 {
+		return value
+	}
+	return def
+}
+
+// configPrompter asks for config values on the terminal.
+type configPrompter struct {
+	reader         *bufio.Reader
+	nonInteractive bool
+}
+
+// Asks a question and returns the answer, or the default if the answer is
+// empty or prompting is disabled.
+func (p *configPrompter) ask(question string, def string) (string, error) {
+	if p.nonInteractive {
+		return def, nil
+	}
+
+	if len(def) > 0 {
+		fmt.Fprintf(os.Stderr, "%s [%s]: ", question, def)
+	} else {
+		fmt.Fprintf(os.Stderr, "%s: ", question)
+	}
+
+	answer, err := p.reader.ReadString('\n')
+	if errors.Is(err, io.EOF) && len(answer) == 0 {
+		return "", errors.New("no answer given, use --non-interactive to use the defaults")
+	}
+	if err != nil && !errors.Is(err, io.EOF) {
+		return "", err
+	}
+
+	answer = strings.TrimSpace(answer)
+	if len(answer) == 0 {
+		return def, nil
+	}
+	return answer, nil
+}
+
+func init() {
+	configCmd.AddCommand(configInitCmd)
+
+	flags := configInitCmd.Flags()
+	flags.BoolVar(
+		&configInitCmdArgs.nonInteractive,
+		"non-interactive",
+		false,
+		"Do not prompt, use the flags and detected values.",
+	)
+
+	flags.BoolVar(
+		&configInitCmdArgs.force,
+		"force",
+		false,
+		"Overwrite an existing config file.",
+	)
+
+	for _, flag := range []struct {
+		value *string
+		name  string
+		def   string
+		usage string
+	}{
+		{&configInitCmdArgs.hostUser, "hostUser", "", "Host user name (default is $USER)."},
+		{&configInitCmdArgs.ocUser, "ocUser", "", "OpenShift CLI user (default is the host user)."},
+		{&configInitCmdArgs.userHome, "userHome", "", "Host user home directory (default is $HOME)."},
+		{&configInitCmdArgs.backplaneConfigProd, "backplaneConfigProd", "", "Backplane config filename of OCM production (default is detected)."},
+		{&configInitCmdArgs.backplaneConfigStage, "backplaneConfigStage", "", "Backplane config filename of OCM staging (default is detected)."},
+		{&configInitCmdArgs.ocmLongLivedTokenPath, "ocmLongLivedTokenPath", "", "OCM offline token file (default is to use 'ocm token')."},
+		{&configInitCmdArgs.containerEngine, "containerEngine", "", "Container engine (default is the first installed of podman, docker and nerdctl)."},
+		{&configInitCmdArgs.baseImage, "baseImage", "", "Base image of the workspace image (default is the Dockerfile default)."},
+		{&configInitCmdArgs.ocmCLIVersion, "ocmCLIVersion", "", "OCM CLI version (default is the Dockerfile default)."},
+		{&configInitCmdArgs.backplaneCLIVersion, "backplaneCLIVersion", "", "Backplane CLI version (default is the Dockerfile default)."},
+	} {
+		flags.StringVar(flag.value, flag.name, flag.def, flag.usage)
+	}
+}
@@ -0,0 +1,52 @@
+/*
+Copyright © 2023 Jose Cueto
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+package cmd
+
+import (
+	"fmt"
+
+	logger "github.com/sirupsen/logrus"
+	"github.com/spf13/cobra"
+
+	pkgInt "ocm-workspace/internal"
+)
+
+var configSchemaCmd = &cobra.Command{
+	Use:   "schema",
+	Short: "Prints the JSON Schema of the config file.",
+	Long: `Prints the JSON Schema of the config file for editor autocompletion, e.g. with the YAML
+language server:
+
+  workspace config schema > ~/.ocm-workspace.schema.json
+
+and the following first line in the config file.
+
+  # yaml-language-server: $schema=.ocm-workspace.schema.json`,
+	Args:        cobra.NoArgs,
+	Annotations: map[string]string{skipConfigAnnotation: "true"},
+	PreRun:      toggleDebug,
+	Run: func(cmd *cobra.Command, args []string) {
+		schema, err := pkgInt.GenerateJSONSchema()
+		if err != nil {
+			logger.Fatal("Failed to generate the JSON Schema: ", err)
+		}
+		fmt.Println(string(schema))
+	},
+}
+
+func init() {
+	configCmd.AddCommand(configSchemaCmd)
+}
@@ -0,0 +1,105 @@
+/*
+Copyright © 2023 Jose Cueto
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+package cmd
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"text/tabwriter"
+
+	logger "github.com/sirupsen/logrus"
+	"github.com/spf13/cobra"
+	"gopkg.in/yaml.v3"
+)
+
+var (
+	configShowCmdArgs struct {
+		cluster string
+		origin  bool
+	}
+)
+
+var configShowCmd = &cobra.Command{
+	Use:   "show",
+	Short: "Prints the effective config.",
+	Long: `Prints the effective config, i.e. the config file with the selected profile (and the profiles
+it extends) merged in, e.g.
+
+  workspace config show --profile oncall
+
+With --cluster the matching clusters entries are overlaid as well. With --origin the config
+file (or profile) that each value came from is printed instead.`,
+	Args:        cobra.NoArgs,
+	Annotations: map[string]string{skipValidationAnnotation: "true"},
+	PreRun:      toggleDebug,
+	Run: func(cmd *cobra.Command, args []string) {
+		if configShowCmdArgs.origin {
+			printConfigOrigins()
+			return
+		}
+
+		effectiveConfig := config
+		if len(configShowCmdArgs.cluster) > 0 {
+			effectiveConfig = config.ForCluster(configShowCmdArgs.cluster)
+		}
+
+		content, err := yaml.Marshal(effectiveConfig)
+		if err != nil {
+			logger.Fatal("Failed to marshal config: ", err)
+		}
+		fmt.Print(string(content))
+	},
+}
+
+// Prints the config values with the file or profile they came from.
+func printConfigOrigins() {
+	w := tabwriter.NewWriter(os.Stdout, 0, 0, 3, ' ', 0)
+	fmt.Fprintln(w, "KEY\tVALUE\tORIGIN")
+	for _, origin := range configSources.GetOrigins() {
+		value, err := json.Marshal(configSources.Get(origin[0]))
+		if err != nil {
+			logger.Fatal("Failed to marshal config value: ", err)
+		}
+		fmt.Fprintf(w, "%s\t%s\t%s\n", origin[0], truncate(string(value), 50), origin[1])
+	}
+	w.Flush()
+}
+
+func truncate(value string, length int) string {
+	if len(value) <= length {
+		return value
+	}
+	return value[:length-3] + "..."
+}
+
+func init() {
+	configCmd.AddCommand(configShowCmd)
+	configShowCmd.Flags().StringVarP(
+		&configShowCmdArgs.cluster,
+		"cluster",
+		"c",
+		"",
+		"Cluster name or id to overlay the clusters config of.",
+	)
+
+	configShowCmd.Flags().BoolVar(
+		&configShowCmdArgs.origin,
+		"origin",
+		false,
+		"Print the config file or profile that each value came from.",
+	)
+}
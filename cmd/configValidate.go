@@ -0,0 +1,53 @@
+/*
+Copyright © 2023 Jose Cueto
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+package cmd
+
+import (
+	"fmt"
+	"os"
+
+	logger "github.com/sirupsen/logrus"
+	"github.com/spf13/cobra"
+	"github.com/spf13/viper"
+)
+
+var configValidateCmd = &cobra.Command{
+	Use:   "validate",
+	Short: "Validates the config file.",
+	Long: `Validates the config file and reports every problem with its YAML path, e.g.
+
+  plugins[0].runOn: "onLogin" is not one of ocmBackplaneLoginSuccess`,
+	Args:        cobra.NoArgs,
+	Annotations: map[string]string{skipValidationAnnotation: "true"},
+	PreRun:      toggleDebug,
+	Run: func(cmd *cobra.Command, args []string) {
+		errs := config.Validate()
+		if len(errs) == 0 {
+			logger.Infof("Config file %s is valid", viper.ConfigFileUsed())
+			return
+		}
+
+		for _, err := range errs {
+			fmt.Println(err)
+		}
+		logger.Errorf("Config file %s has %d problem(s)", viper.ConfigFileUsed(), len(errs))
+		os.Exit(1)
+	},
+}
+
+func init() {
+	configCmd.AddCommand(configValidateCmd)
+}
@@ -0,0 +1,209 @@
+/*
+Copyright © 2023 Jose Cueto
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package cmd
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	logger "github.com/sirupsen/logrus"
+
+	pkgInt "ocm-workspace/internal"
+	pkgIntHelper "ocm-workspace/internal/helpers"
+)
+
+// Runs the plugins of a hook event in the workspace container in their
+// declared order. A failing plugin stops the workspace login.
+func runContainerHooks(event string, envVars [][]string) {
+	err := runHooks(event, envVars)
+	if err != nil {
+		logger.Fatal(err)
+	}
+}
+
+// Runs the plugins of a hook event in the workspace container in their
+// declared order.
+func runHooks(event string, envVars [][]string) error {
+	hookEvent, ok := pkgInt.GetHookEvent(event)
+	if !ok || hookEvent.OnHost {
+		return fmt.Errorf("%s is not a workspace container hook event", event)
+	}
+
+	for _, plug := range config.GetHookPlugins(event) {
+		// Create (overwrite) plugin config
+		configPath := fmt.Sprintf("%s/.%s.yaml", config.UserHome, plug.Name)
+		createConfigFile(configPath, plug.Config)
+
+		pluginEnvVars := append(getHookEnvVars(event), getPluginPortEnvVars(plug.Name)...)
+		for _, name := range plug.Env {
+			pluginEnvVars = append(pluginEnvVars, []string{name, os.Getenv(name)})
+		}
+		err := runPlugin(plug, configPath, append(pluginEnvVars, envVars...), hookEvent.Background)
+		if err != nil {
+			return fmt.Errorf("plugin %s failed on %s: %v", plug.Name, event, err)
+		}
+	}
+	return nil
+}
+
+// Runs a plugin mounted in the workspace container as the host user.
+func runPlugin(plug pkgInt.Plugin, configPath string, envVars [][]string, background bool) error {
+	executable := fmt.Sprintf("/usr/bin/%s", filepath.Base(plug.ExecPath))
+	cmdArgs := []string{plug.ExecCommand, "--config", configPath}
+	if debug {
+		cmdArgs = append(cmdArgs, "-d")
+	}
+
+	// The shell hooks already run as the host user
+	cmdName := executable
+	if os.Getuid() == 0 {
+		cmdName = "sudo"
+		cmdArgs = append([]string{"-Eu", getEnvVar("HOST_USER"), executable}, cmdArgs...)
+	}
+	logger.Debugf("Running plugin with args: %v %v", pkgIntHelper.RedactArgs(cmdArgs), envVars)
+
+	if background {
+		return getPluginSupervisor().Start(plug.Name, plug.RestartPolicy, cmdName, cmdArgs, envVars)
+	}
+	return pkgIntHelper.RunCommandWithEnv(cmdName, cmdArgs, envVars)
+}
+
+// Time the plugins have to exit after SIGTERM
+const pluginStopTimeout = 10 * time.Second
+
+// Supervises the background plugins of the workspace login shell
+var pluginSupervisor *pkgInt.PluginSupervisor
+
+func getPluginSupervisor() *pkgInt.PluginSupervisor {
+	if pluginSupervisor == nil {
+		var err error
+		pluginSupervisor, err = pkgInt.NewPluginSupervisor(pkgInt.ContainerPluginStateDir)
+		if err != nil {
+			logger.Fatal("Failed to create the plugin supervisor: ", err)
+		}
+	}
+	return pluginSupervisor
+}
+
+// Stops the background plugins started by the workspace login.
+func stopPlugins() {
+	if pluginSupervisor != nil {
+		pluginSupervisor.Stop(pluginStopTimeout)
+	}
+}
+
+// Runs the preLogin plugins on the host in their declared order. Their
+// config files are written to the workspace's secrets directory.
+func runHostHooks(conf *pkgInt.OcmWorkspaceConfig, containerName string, envVars [][]string) error {
+	plugins := conf.GetHookPlugins(pkgInt.HookPreLogin)
+	if len(plugins) == 0 {
+		return nil
+	}
+
+	secretsDir, err := pkgInt.GetWorkspaceSecretsDir(containerName)
+	if err != nil {
+		return err
+	}
+
+	for _, plug := range plugins {
+		configPath := filepath.Join(secretsDir, fmt.Sprintf("%s.yaml", plug.Name))
+		err = os.WriteFile(configPath, []byte(plug.Config), 0600)
+		if err != nil {
+			return err
+		}
+
+		cmdArgs := []string{plug.ExecCommand, "--config", configPath}
+		if debug {
+			cmdArgs = append(cmdArgs, "-d")
+		}
+		logger.Debugf("Running plugin with args: %v %v", pkgIntHelper.RedactArgs(cmdArgs), envVars)
+
+		hookEnvVars := append([][]string{{"HOOK_EVENT", pkgInt.HookPreLogin}}, envVars...)
+		err = pkgIntHelper.RunCommandWithEnv(plug.ExecPath, cmdArgs, hookEnvVars)
+		if err != nil {
+			return fmt.Errorf("plugin %s failed on %s: %v", plug.Name, pkgInt.HookPreLogin, err)
+		}
+	}
+	return nil
+}
+
+// Gets the environment variables passed to the plugins of a hook event in
+// the workspace container.
+func getHookEnvVars(event string) [][]string {
+	return [][]string{
+		{"HOOK_EVENT", event},
+		{"PLUGIN_SERVICE", getEnvVar("PLUGIN_SERVICE")},
+		{"HOST_USER", getEnvVar("HOST_USER")},
+		{"OCM_CLUSTER", getEnvVar("OCM_CLUSTER")},
+		{"OCM_ENVIRONMENT", getEnvVar("OCM_ENVIRONMENT")},
+	}
+}
+
+// Gets the PLUGIN_PORTS (container ports) and PLUGIN_HOST_PORTS environment
+// variables of a plugin, comma separated in the same order.
+func getPluginPortEnvVars(name string) [][]string {
+	var containerPorts, hostPorts []string
+	for _, pm := range pkgInt.ParsePluginPorts(getEnvVar("PLUGIN_PORT_MAPS"))[name] {
+		containerPorts = append(containerPorts, pm.ContainerPort)
+		hostPorts = append(hostPorts, pm.HostPort)
+	}
+	return [][]string{
+		{"PLUGIN_PORTS", strings.Join(containerPorts, ",")},
+		{"PLUGIN_HOST_PORTS", strings.Join(hostPorts, ",")},
+	}
+}
+
+// Gets the .bashrc lines that run the shell hooks in the interactive shells
+// of the workspace container, only for the events with plugins.
+func getShellHooks() string {
+	var hooks string
+	if len(config.GetHookPlugins(pkgInt.HookShellStart)) > 0 {
+		hooks += fmt.Sprintf("\n/usr/bin/workspace runHooks %s", pkgInt.HookShellStart)
+	}
+	if len(config.GetHookPlugins(pkgInt.HookShellExit)) > 0 {
+		hooks += fmt.Sprintf("\ntrap '/usr/bin/workspace runHooks %s' EXIT", pkgInt.HookShellExit)
+	}
+	if len(config.GetHookPlugins(pkgInt.HookNamespaceChange)) > 0 {
+		hooks += fmt.Sprintf(namespaceChangeShellHook, pkgInt.HookNamespaceChange)
+	}
+	return hooks + "\n"
+}
+
+// Shell hook of the namespaceChange event, run before each prompt. The
+// namespace is only read when the kubeconfig changed and the plugins only run
+// when the namespace changed, both tracked per shell.
+const namespaceChangeShellHook = `
+__ocm_workspace_kubeconfig_mtime() {
+	local IFS=:
+	stat -c %%Y -- ${KUBECONFIG:-$HOME/.kube/config} 2>/dev/null
+}
+__ocm_workspace_namespace_hook() {
+	local mtime namespace
+	mtime=$(__ocm_workspace_kubeconfig_mtime)
+	[ "$mtime" = "$__ocm_workspace_mtime" ] && return
+	__ocm_workspace_mtime=$mtime
+	namespace=$(oc project -q 2>/dev/null) || return
+	[ "$namespace" = "$__ocm_workspace_namespace" ] && return
+	NAMESPACE=$namespace PREVIOUS_NAMESPACE=$__ocm_workspace_namespace /usr/bin/workspace runHooks %s
+	__ocm_workspace_namespace=$namespace
+}
+__ocm_workspace_mtime=$(__ocm_workspace_kubeconfig_mtime)
+__ocm_workspace_namespace=$(oc project -q 2>/dev/null)
+PROMPT_COMMAND="__ocm_workspace_namespace_hook${PROMPT_COMMAND:+; $PROMPT_COMMAND}"`
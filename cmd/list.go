@@ -0,0 +1,108 @@
+/*
+Copyright © 2023 Jose Cueto
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+package cmd
+
+import (
+	"fmt"
+	"os"
+	"strings"
+	"text/tabwriter"
+	"time"
+
+	logger "github.com/sirupsen/logrus"
+	"github.com/spf13/cobra"
+
+	pkgInt "ocm-workspace/internal"
+	pkgIntHelper "ocm-workspace/internal/helpers"
+)
+
+var (
+	listCmdArgs struct {
+		all    bool
+		output string
+	}
+)
+
+var listCmd = &cobra.Command{
+	Use:         "list",
+	Aliases:     []string{"ps"},
+	Short:       "Lists the workspace containers.",
+	Long:        `Lists the workspace containers created by the login command with their cluster, OCM environment and port maps.`,
+	Annotations: map[string]string{skipValidationAnnotation: "true"},
+	PreRun:      toggleDebug,
+	Run:         onList,
+}
+
+func onList(cmd *cobra.Command, args []string) {
+	workspaces, err := pkgInt.ListWorkspaces(newContainerEngine(), listCmdArgs.all)
+	if err != nil {
+		logger.Fatal("Failed to list workspaces: ", err)
+	}
+
+	if len(listCmdArgs.output) > 0 {
+		err = printStructured(listCmdArgs.output, workspaces)
+		if err != nil {
+			logger.Fatal(err)
+		}
+		return
+	}
+
+	w := tabwriter.NewWriter(os.Stdout, 0, 0, 3, ' ', 0)
+	fmt.Fprintln(w, "NAME\tCLUSTER\tENVIRONMENT\tSTATUS\tAGE\tCONSOLE PORT\tPORT MAPS\tPLUGIN PORTS")
+	for _, ws := range workspaces {
+		fmt.Fprintf(
+			w,
+			"%s\t%s\t%s\t%s\t%s\t%s\t%s\t%s\n",
+			ws.Name,
+			orNone(ws.Cluster),
+			orNone(ws.OcmEnvironment),
+			ws.Status,
+			pkgIntHelper.FormatDuration(time.Since(ws.Created)),
+			orNone(ws.ConsolePort),
+			orNone(pkgInt.FormatPortMaps(ws.CustomPortMaps)),
+			orNone(strings.ReplaceAll(pkgInt.FormatPluginPorts(ws.PluginPorts), ";", " ")),
+		)
+	}
+	w.Flush()
+}
+
+func orNone(value string) string {
+	if len(value) == 0 {
+		return "<none>"
+	}
+	return value
+}
+
+func init() {
+	rootCmd.AddCommand(listCmd)
+
+	flags := listCmd.Flags()
+	flags.BoolVarP(
+		&listCmdArgs.all,
+		"all",
+		"a",
+		false,
+		"Show all workspaces (default shows just running).",
+	)
+
+	flags.StringVarP(
+		&listCmdArgs.output,
+		"output",
+		"o",
+		"",
+		"Output format (json, yaml).",
+	)
+}
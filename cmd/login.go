@@ -27,6 +27,7 @@ import (
 
 	pkgInt "ocm-workspace/internal"
 	pkgIntHelper "ocm-workspace/internal/helpers"
+	pkgIntToken "ocm-workspace/internal/token"
 )
 
 var (
@@ -35,6 +36,7 @@ var (
 		ocmEnvironment string
 		service        string
 		isOcmLoginOnly bool
+		reuse          bool
 	}
 )
 
@@ -47,47 +49,70 @@ var loginCmd = &cobra.Command{
 
 // Runs the OCM Workspace container using the image built by the "build" command
 func onLogin(cmd *cobra.Command, args []string) {
-	ocmEnvironment := "production"
-
-	if len(loginCmdArgs.ocmEnvironment) > 0 {
-		ocmEnvironment = loginCmdArgs.ocmEnvironment
+	ocmEnvironment, err := config.ResolveOcmEnvironment(loginCmdArgs.ocmEnvironment)
+	if err != nil {
+		logger.Fatal(err)
 	}
 
-	ceFactory := pkgInt.NewCeFactory(map[string]interface{}{
-		"ceName": "podman",
-	})
-
 	ocmCluster := loginCmdArgs.cluster
 	isOcmLoginOnly := loginCmdArgs.isOcmLoginOnly
+	clusterConfig := config.ForCluster(ocmCluster)
+
+	ce := newContainerEngine()
+
+	if loginCmdArgs.reuse {
+		ws := findReusableWorkspace(ce, ocmCluster, ocmEnvironment.Name)
+		if ws != nil {
+			err := attachWorkspace(ce, ws)
+			if err != nil {
+				logger.Fatal("Failed to attach to workspace: ", err)
+			}
+			return
+		}
+		logger.Info("No running workspace to reuse, creating a new one.")
+	}
 
-	ce, err := ceFactory.Create()
+	tokenSource, err := pkgIntToken.NewSource(ocmEnvironment.Name, ocmEnvironment.TokenSource)
 	if err != nil {
-		logger.Fatal("Failed to create container engine: ", err)
+		logger.Fatalf("Invalid token source of OCM environment %s: %v", ocmEnvironment.Name, err)
+	}
+	ocmToken, err := tokenSource.GetToken()
+	if err != nil {
+		logger.Fatalf("Failed to get the OCM token from %s: %v", tokenSource, err)
 	}
 
-	ocmLongLivedTokenPath := config.OcmLongLivedTokenPath
-	var ocmToken string
-
-	if len(ocmLongLivedTokenPath) > 0 {
-		content, err := os.ReadFile(ocmLongLivedTokenPath)
-		if err != nil {
-			logger.Fatalf("Failed to open long lived token file: %v", content)
-		}
-		ocmToken = string(content)
-	} else {
-		ocmToken, err = pkgIntHelper.OcmGetOCMToken()
-		if err != nil {
-			logger.Fatal("Failed to fetch the OCM token: ", err)
-		}
+	// Validate the token before launching the container
+	parsedToken, err := pkgIntToken.ParseAndValidate(ocmToken, ocmEnvironment.GetTokenValidateOptions())
+	if err != nil {
+		logger.Fatalf("Invalid OCM token from %s: %v", tokenSource, err)
 	}
+	ocmToken = parsedToken.Raw
+	logger.Debugf("Using OCM %s token", parsedToken.Kind())
 
 	// Path where backplane config is mounted in the container
 	containerBackplaneConfigPath := "/backplane-config.json"
-	// Path where workspace config is mounted in the container
-	ocmWorkspaceConfigPath := "/.ocm-workspace.yaml"
+	suffix := uuid.New()
+	containerName := fmt.Sprintf("ow-%s-%s", ocmCluster, suffix.String()[:6])
+
+	err = runHostHooks(clusterConfig, containerName, [][]string{
+		{"OCM_CLUSTER", ocmCluster},
+		{"OCM_ENVIRONMENT", ocmEnvironment.Name},
+		{"HOST_USER", config.HostUser},
+		{"WORKSPACE_CONTAINER_NAME", containerName},
+	})
+	if err != nil {
+		logger.Fatal(err)
+	}
+
+	// Lease the host ports of the container until it is removed
+	portLeases, err := pkgInt.NewPortLeases()
+	if err != nil {
+		logger.Fatal("Failed to load port leases: ", err)
+	}
+	defer portLeases.Close()
 
 	// Allocate free port and map host port for OpenShift console
-	ports, err := pkgIntHelper.GetFreePorts(1)
+	ports, err := portLeases.Reserve(containerName, 1)
 	if err != nil {
 		logger.Fatal("Failed to generate port for Openshift console: ", err)
 	}
@@ -98,39 +123,64 @@ func onLogin(cmd *cobra.Command, args []string) {
 	ce.AppendEnvVar("OC_USER", config.OcUser)
 	ce.AppendEnvVar("OCM_CLUSTER", ocmCluster)
 	ce.AppendEnvVar("IS_OCM_LOGIN_ONLY", strconv.FormatBool(isOcmLoginOnly))
-	ce.AppendEnvVar("OCM_TOKEN", ocmToken)
+	ce.AppendEnvVar("OCM_TOKEN_FILE", pkgInt.ContainerOcmTokenPath)
 	ce.AppendEnvVar("IS_IN_CONTAINER", "true")
-	ce.AppendEnvVar("OCM_ENVIRONMENT", ocmEnvironment)
-	ce.AppendEnvVar("BACKPLANE_CONFIG", containerBackplaneConfigPath)
+	ce.AppendEnvVar("OCM_ENVIRONMENT", ocmEnvironment.Name)
+	ce.AppendEnvVar("OCM_URL", ocmEnvironment.Url)
 	ce.AppendEnvVar("OPENSHIFT_CONSOLE_PORT", openshiftConsolePort)
 	ce.AppendEnvVar("PLUGIN_SERVICE", loginCmdArgs.service)
+	ce.AppendEnvVar("OCM_WORKSPACE_CONFIG", pkgInt.ContainerConfigPath)
+	for _, env := range ocmEnvironment.Proxy.GetEnvVars() {
+		ce.AppendEnvVar(env[0], env[1])
+	}
 
 	// Gather values for the container's host-mounted volumes
-	if ocmEnvironment == "production" {
+	// The OCM token is passed as a file only readable by the host user
+	// instead of an environment variable visible in the container's config.
+	// It is kept for the restarts of the container and removed with the
+	// other secrets by rm and prune.
+	ocmTokenPath, err := pkgInt.WriteWorkspaceOcmToken(containerName, ocmToken)
+	if err != nil {
+		logger.Fatal("Failed to write the OCM token file: ", err)
+	}
+	ce.AppendVolMap(ocmTokenPath, pkgInt.ContainerOcmTokenPath, "ro")
+
+	if len(ocmEnvironment.BackplaneConfig) > 0 {
+		ce.AppendEnvVar("BACKPLANE_CONFIG", containerBackplaneConfigPath)
 		ce.AppendVolMap(
-			fmt.Sprintf("%s/.config/backplane/%s", config.UserHome, config.BackplaneConfigProd),
+			fmt.Sprintf("%s/.config/backplane/%s", config.UserHome, ocmEnvironment.BackplaneConfig),
 			containerBackplaneConfigPath,
 			"ro",
 		)
 	} else {
-		ce.AppendVolMap(
-			fmt.Sprintf("%s/.config/backplane/%s", config.UserHome, config.BackplaneConfigStage),
-			containerBackplaneConfigPath,
-			"ro",
-		)
+		logger.Warnf("No backplane config set for OCM environment %s, backplane uses its default config.", ocmEnvironment.Name)
 	}
 	ce.AppendVolMap("./terminal", "/terminal", "ro")
-	ce.AppendVolMap(fmt.Sprintf("%s/.ocm-workspace.yaml", config.UserHome), ocmWorkspaceConfigPath, "ro")
 
-	for _, dirMap := range config.CustomDirMaps {
+	// The container uses the effective config resolved on the host
+	configPath, err := pkgInt.WriteWorkspaceConfig(containerName, clusterConfig)
+	if err != nil {
+		logger.Fatal("Failed to write the workspace config: ", err)
+	}
+	ce.AppendVolMap(configPath, pkgInt.ContainerConfigPath, "ro")
+
+	for _, dirMap := range clusterConfig.CustomDirMaps {
 		ce.AppendVolMap(dirMap.HostDir, dirMap.ContainerDir, dirMap.FileAttrs)
 	}
 
-	// Mount plugin executables
-	plugins := config.Plugins
+	// Mount plugin executables and pass their host environment variables
+	plugins := clusterConfig.Plugins
 	for _, plug := range plugins {
 		executable := filepath.Base(plug.ExecPath)
 		ce.AppendVolMap(plug.ExecPath, fmt.Sprintf("/usr/bin/%s", executable), "ro")
+
+		for _, name := range plug.Env {
+			value, ok := os.LookupEnv(name)
+			if !ok {
+				logger.Fatalf("Plugin %s requires the environment variable %s", plug.Name, name)
+			}
+			ce.AppendEnvVar(name, value)
+		}
 	}
 
 	// Gather values for the containers host-mapped TCP ports
@@ -138,19 +188,51 @@ func onLogin(cmd *cobra.Command, args []string) {
 	ce.AppendPortMap(openshiftConsolePort, openshiftConsolePort, "127.0.0.1")
 
 	var customPortMaps string
+	var allocatedPortMaps []pkgInt.PortMap
 	for _, pm := range config.CustomPortMaps {
-		ports, err = pkgIntHelper.GetFreePorts(1)
+		ports, err = portLeases.Reserve(containerName, 1)
 		if err != nil {
 			logger.Fatalf("Failed to allocate host ports: %v", err)
 		}
 		pm.HostPort = strconv.Itoa(ports[0])
 		customPortMaps += fmt.Sprintf("%s:%s,", pm.HostPort, pm.ContainerPort)
 		ce.AppendPortMap(pm.HostPort, pm.ContainerPort, "127.0.0.1")
+		allocatedPortMaps = append(allocatedPortMaps, pm)
 	}
 	ce.AppendEnvVar("CUSTOM_PORT_MAPS", customPortMaps)
 
-	suffix := uuid.New()
-	containerName := fmt.Sprintf("ow-%s-%s", ocmCluster, suffix.String()[:6])
+	// Each plugin gets its own host ports, mapped to the same container ports
+	pluginPorts := map[string][]pkgInt.PortMap{}
+	for _, plug := range plugins {
+		if plug.AllocatePorts <= 0 {
+			continue
+		}
+		ports, err = portLeases.Reserve(containerName, plug.AllocatePorts)
+		if err != nil {
+			logger.Fatalf("Failed to allocate host ports for plugin %s: %v", plug.Name, err)
+		}
+		for _, port := range ports {
+			pm := pkgInt.PortMap{HostPort: strconv.Itoa(port), ContainerPort: strconv.Itoa(port)}
+			ce.AppendPortMap(pm.HostPort, pm.ContainerPort, "127.0.0.1")
+			pluginPorts[plug.Name] = append(pluginPorts[plug.Name], pm)
+		}
+	}
+	ce.AppendEnvVar("PLUGIN_PORT_MAPS", pkgInt.FormatPluginPorts(pluginPorts))
+
+	// Other commands wait for the lock while the workspace runs otherwise
+	err = portLeases.Close()
+	if err != nil {
+		logger.Fatal("Failed to unlock port leases: ", err)
+	}
+
+	// Labels used to discover the workspace containers
+	ce.AppendLabel(pkgInt.WorkspaceLabel, "true")
+	ce.AppendLabel(pkgInt.WorkspaceClusterLabel, ocmCluster)
+	ce.AppendLabel(pkgInt.WorkspaceEnvironmentLabel, ocmEnvironment.Name)
+	ce.AppendLabel(pkgInt.WorkspaceHostUserLabel, config.HostUser)
+	ce.AppendLabel(pkgInt.WorkspaceConsolePortLabel, openshiftConsolePort)
+	ce.AppendLabel(pkgInt.WorkspaceCustomPortMapsLabel, pkgInt.FormatPortMaps(allocatedPortMaps))
+	ce.AppendLabel(pkgInt.WorkspacePluginPortsLabel, pkgInt.FormatPluginPorts(pluginPorts))
 
 	runCmd := ce.GetRunArgs(
 		containerName,
@@ -159,14 +241,14 @@ func onLogin(cmd *cobra.Command, args []string) {
 		"clusterLogin",
 		ocmCluster,
 		"--config",
-		ocmWorkspaceConfigPath,
+		pkgInt.ContainerConfigPath,
 	)
 
 	if debug {
 		runCmd = append(runCmd, "-d")
 	}
 
-	logger.Debugf("Container run command: %v", runCmd)
+	logger.Debugf("Container run command: %v", pkgIntHelper.RedactArgs(runCmd, ocmToken))
 
 	pkgIntHelper.RunCommandWithOsFiles(
 		ce.GetExecName(),
@@ -177,6 +259,23 @@ func onLogin(cmd *cobra.Command, args []string) {
 	)
 }
 
+// Finds the most recent running workspace logged into the same cluster and
+// OCM environment.
+func findReusableWorkspace(ce pkgInt.ContainerEngine, ocmCluster string, ocmEnvironment string) *pkgInt.Workspace {
+	workspaces, err := pkgInt.ListWorkspaces(ce, false)
+	if err != nil {
+		logger.Fatal("Failed to list workspaces: ", err)
+	}
+
+	var found *pkgInt.Workspace
+	for idx, ws := range workspaces {
+		if ws.Cluster == ocmCluster && ws.OcmEnvironment == ocmEnvironment {
+			found = &workspaces[idx]
+		}
+	}
+	return found
+}
+
 func init() {
 	rootCmd.AddCommand(loginCmd)
 
@@ -194,7 +293,7 @@ func init() {
 		"ocmEnvironment",
 		"e",
 		"production",
-		"OCM environemnt (production, staging)",
+		"OCM environment (production, staging, integration or an ocmEnvironments entry).",
 	)
 
 	flags.StringVarP(
@@ -211,4 +310,11 @@ func init() {
 		false,
 		"Log in to OCM only.",
 	)
+
+	flags.BoolVar(
+		&loginCmdArgs.reuse,
+		"reuse",
+		false,
+		"Open a shell in a running workspace of the same cluster and OCM environment instead of creating a new one.",
+	)
 }
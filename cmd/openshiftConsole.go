@@ -18,6 +18,8 @@ package cmd
 import (
 	"encoding/json"
 	"fmt"
+	"net/http"
+	"time"
 
 	logger "github.com/sirupsen/logrus"
 
@@ -27,9 +29,18 @@ import (
 	"github.com/spf13/cobra"
 	"github.com/spf13/viper"
 
+	pkgInt "ocm-workspace/internal"
 	pkgIntHelper "ocm-workspace/internal/helpers"
+	pkgIntToken "ocm-workspace/internal/token"
 )
 
+// Time before the bearer token expiry when the console is restarted with a
+// fresh token.
+const consoleTokenRefreshMargin = time.Minute
+
+// Interval of polling the OCM CLI for a fresh token.
+const consoleTokenPollInterval = 15 * time.Second
+
 type ocDeploymentContainer struct {
 	Name  string `json:"name"`
 	Image string `json:"image"`
@@ -54,53 +65,99 @@ var (
 	consoleCmdArgs struct {
 		workspaceContainerName string
 		workspaceContainerPort string
+		open                   bool
+		detach                 bool
 	}
 )
 
 // openshiftConsoleCmd represents the openshiftConsole command
 var openshiftConsoleCmd = &cobra.Command{
-	Use:   "openshiftConsole",
+	Use:   "openshiftConsole [cluster or container]",
 	Short: "Launches an OpenShift console.",
-	Long:  `Launches an OpenShift console application in a separate container.`,
-	Run: func(cmd *cobra.Command, args []string) {
-		ocUser := viper.GetString("ocUser")
-		userHome := viper.GetString("userHome")
-		out, err := pkgIntHelper.RunCommandPipeStdin("ocm", "post", "/api/accounts_mgmt/v1/access_token")
-		if err != nil {
-			logger.Fatal("Failed to run command: ", err)
-		}
-		path := fmt.Sprintf("%s/.kube/ocm-pull-secret/config.json", userHome)
-		file, err := os.OpenFile(path, os.O_WRONLY, 0644)
-		if err != nil {
-			logger.Fatal("Failed to open file: ", err)
-		}
-		defer file.Close()
-		file.WriteString(string(out))
-
-		containerName := fmt.Sprintf("%s-openshift-console", consoleCmdArgs.workspaceContainerName)
-		kubeConfigFileName := fmt.Sprintf("%s/.kube/ocm-pull-secret/config.json", userHome)
-		consoleListenAddr := fmt.Sprintf("http://0.0.0.0:%s", consoleCmdArgs.workspaceContainerPort)
-		pullArgs := []string{"pull", "--quiet", "--authfile", kubeConfigFileName}
-		runArgs := []string{
-			"run",
-			"--rm",
-			"--network",
-			fmt.Sprintf("container:%s", consoleCmdArgs.workspaceContainerName),
-			"-e",
-			"HTTPS_PROXY=http://squid.corp.redhat.com:3128",
-			"--name",
-			containerName,
-			"--authfile",
-			kubeConfigFileName,
+	Long: `Launches an OpenShift console application in a separate container.
+The workspace is looked up by its cluster, container name or ID. If there is only one running workspace, the argument can be omitted.
+The console is restarted with a fresh bearer token before its token expires.`,
+	Args:   cobra.MaximumNArgs(1),
+	PreRun: toggleDebug,
+	Run:    onOpenshiftConsole,
+}
+
+// consoleLauncher runs the OpenShift console container of a workspace.
+type consoleLauncher struct {
+	workspaceContainerName string
+	workspaceContainerPort string
+	ocmEnvironment         string
+	ocUser                 string
+	userHome               string
+	containerName          string
+	consoleUrl             string
+	consoleImage           string
+	apiUrl                 string
+	alertManagerUrl        string
+	thanosUrl              string
+}
+
+func newConsoleLauncher(workspaceContainerName string, workspaceContainerPort string, ocmEnvironment string) *consoleLauncher {
+	return &consoleLauncher{
+		workspaceContainerName: workspaceContainerName,
+		workspaceContainerPort: workspaceContainerPort,
+		ocmEnvironment:         ocmEnvironment,
+		ocUser:                 viper.GetString("ocUser"),
+		userHome:               viper.GetString("userHome"),
+		containerName:          pkgInt.GetConsoleContainerName(workspaceContainerName),
+		consoleUrl:             fmt.Sprintf("http://localhost:%s", workspaceContainerPort),
+	}
+}
+
+func onOpenshiftConsole(cmd *cobra.Command, args []string) {
+	ce := newContainerEngine()
+
+	workspaceContainerName, workspaceContainerPort, ocmEnvironment := resolveConsoleWorkspace(ce, args)
+
+	consoles, err := pkgInt.ListConsoles(ce)
+	if err != nil {
+		logger.Fatal("Failed to list OpenShift consoles: ", err)
+	}
+	if console := pkgInt.FindConsole(consoles, workspaceContainerName); console != nil {
+		logger.Fatalf(
+			"OpenShift console of %s is already running at %s (image: %s)",
+			workspaceContainerName,
+			console.Url,
+			console.Image,
+		)
+	}
+
+	launcher := newConsoleLauncher(workspaceContainerName, workspaceContainerPort, ocmEnvironment)
+	launcher.prepare()
+
+	logger.Infof("OpenShift console of %s will be available at %s", workspaceContainerName, launcher.consoleUrl)
+
+	if consoleCmdArgs.detach {
+		expiry := launcher.runDetached()
+		launcher.startRefresher(expiry, launcher.getConsoleID())
+		logger.Infof("OpenShift console container %s is running in the background", launcher.containerName)
+		if consoleCmdArgs.open {
+			openWhenAvailable(launcher.consoleUrl)
 		}
+		return
+	}
+
+	if consoleCmdArgs.open {
+		go openWhenAvailable(launcher.consoleUrl)
+	}
+	launcher.runForeground()
+}
+
+// Pulls the cluster's console image and gathers the cluster URLs.
+func (cl *consoleLauncher) prepare() {
+	ce := newContainerEngine()
 
-		out, err = pkgIntHelper.RunCommandOutput(
-			"podman",
-			"exec",
-			"-it",
-			"--user",
-			ocUser,
-			consoleCmdArgs.workspaceContainerName,
+	out, err := pkgIntHelper.RunCommandOutput(
+		ce.GetExecName(),
+		ce.GetExecArgs(
+			cl.workspaceContainerName,
+			cl.ocUser,
+			false,
 			"oc",
 			"get",
 			"deployment",
@@ -109,113 +166,421 @@ var openshiftConsoleCmd = &cobra.Command{
 			"openshift-console",
 			"-o",
 			"json",
-		)
-		if err != nil {
-			logger.Fatal("Failed to run command: ", err)
-		}
-		var openShiftConsoleDeploy ocDeployment
-		var consoleImage string
-		err = json.Unmarshal(out, &openShiftConsoleDeploy)
-		if err != nil {
-			logger.Fatal("Failed to unmarshal: ", err)
-		}
-		fmt.Println(openShiftConsoleDeploy)
+		)...,
+	)
+	if err != nil {
+		logger.Fatal("Failed to run command: ", err)
+	}
+	var openShiftConsoleDeploy ocDeployment
+	err = json.Unmarshal(out, &openShiftConsoleDeploy)
+	if err != nil {
+		logger.Fatal("Failed to unmarshal: ", err)
+	}
+	logger.Debugf("OpenShift console deployment: %v", openShiftConsoleDeploy)
 
-		for _, container := range openShiftConsoleDeploy.Spec.Template.Spec.Containers {
-			if container.Name == "console" {
-				consoleImage = container.Image
-				break
-			}
+	for _, container := range openShiftConsoleDeploy.Spec.Template.Spec.Containers {
+		if container.Name == "console" {
+			cl.consoleImage = container.Image
+			break
 		}
+	}
 
-		out, err = pkgIntHelper.RunCommandOutput(
-			"podman",
-			"exec",
-			"-it",
-			"--user",
-			ocUser,
-			consoleCmdArgs.workspaceContainerName,
+	cl.pullConsoleImage(ce)
+	cl.loadClusterUrls(ce)
+}
+
+// Gathers the cluster URLs of the console from the workspace's oc config.
+func (cl *consoleLauncher) loadClusterUrls(ce pkgInt.ContainerEngine) {
+	out, err := pkgIntHelper.RunCommandOutput(
+		ce.GetExecName(),
+		ce.GetExecArgs(
+			cl.workspaceContainerName,
+			cl.ocUser,
+			false,
 			"oc",
 			"config",
 			"view",
 			"-o",
 			"json",
-		)
+		)...,
+	)
+	if err != nil {
+		logger.Fatal("Failed to run command: ", err)
+	}
+
+	var ocConfig pkgIntHelper.OcConfig
+	err = json.Unmarshal(out, &ocConfig)
+	if err != nil {
+		logger.Fatal("Failed to unmarshal: ", err)
+	}
+
+	cluster := ocConfig.Clusters[0]
+	cl.apiUrl = cluster.ClusterUrls.Server
+	cl.alertManagerUrl = strings.Replace(cl.apiUrl, "/backplane/cluster", "/backplane/alertmanager", 1)
+	cl.thanosUrl = strings.Replace(cl.apiUrl, "/backplane/cluster", "/backplane/thanos", 1)
+	cl.alertManagerUrl = strings.TrimRight(cl.alertManagerUrl, "/")
+	cl.thanosUrl = strings.TrimRight(cl.thanosUrl, "/")
+}
+
+// Pulls the console image unless it is present locally, and records the
+// OpenShift version it is used for in the console image cache.
+func (cl *consoleLauncher) pullConsoleImage(ce pkgInt.ContainerEngine) {
+	cache, err := pkgInt.NewConsoleImageCache()
+	if err != nil {
+		logger.Fatal("Failed to load the console image cache: ", err)
+	}
+
+	digest, err := pkgInt.GetLocalImageDigest(ce, cl.consoleImage)
+	if err != nil {
+		logger.Debugf("Pulling console image: %v", err)
+
+		out, err := pkgIntHelper.RunCommandPipeStdin("ocm", "post", "/api/accounts_mgmt/v1/access_token")
 		if err != nil {
 			logger.Fatal("Failed to run command: ", err)
 		}
-
-		var config pkgIntHelper.OcConfig
-		err = json.Unmarshal(out, &config)
+		kubeConfigFileName := fmt.Sprintf("%s/.kube/ocm-pull-secret/config.json", cl.userHome)
+		err = os.WriteFile(kubeConfigFileName, out, 0600)
 		if err != nil {
-			logger.Fatal("Failed to unmarshal: ", err)
+			logger.Fatal("Failed to write the pull secret: ", err)
 		}
 
-		imagePullArgs := append(pullArgs, consoleImage)
-		_, err = pkgIntHelper.RunCommandOutput(
-			"podman",
-			imagePullArgs...,
+		_, err = pkgIntHelper.RunCommandOutputWithEnv(
+			ce.GetExecName(),
+			ce.GetRegistryAuthEnvVars(kubeConfigFileName),
+			ce.GetPullArgs(cl.consoleImage)...,
 		)
 		if err != nil {
 			logger.Fatal("Failed to run command: ", err)
 		}
-		cluster := config.Clusters[0]
-		apiUrl := cluster.ClusterUrls.Server
-		alertManagerUrl := strings.Replace(apiUrl, "/backplane/cluster", "/backplane/alertmanager", 1)
-		thanosUrl := strings.Replace(apiUrl, "/backplane/cluster", "/backplane/thanos", 1)
-		alertManagerUrl = strings.TrimRight(alertManagerUrl, "/")
-		thanosUrl = strings.TrimRight(thanosUrl, "/")
-
-		out, err = pkgIntHelper.RunCommandOutput(
-			"podman",
-			"exec",
-			"-it",
-			"--user",
-			ocUser,
-			consoleCmdArgs.workspaceContainerName,
+
+		digest, err = pkgInt.GetLocalImageDigest(ce, cl.consoleImage)
+		if err != nil {
+			logger.Fatal("Failed to inspect the console image: ", err)
+		}
+	} else {
+		logger.Debugf("Using local console image %s (%s)", cl.consoleImage, digest)
+	}
+
+	out, err := pkgIntHelper.RunCommandOutput(
+		ce.GetExecName(),
+		ce.GetExecArgs(
+			cl.workspaceContainerName,
+			cl.ocUser,
+			false,
+			"oc",
+			"get",
+			"clusterversion",
+			"version",
+			"-o",
+			"jsonpath={.status.desired.version}",
+		)...,
+	)
+	if err != nil {
+		logger.Warnf("Failed to get the OpenShift version: %v", err)
+	}
+
+	err = cache.Record(cl.consoleImage, digest, strings.TrimSpace(string(out)))
+	if err != nil {
+		logger.Errorf("Failed to record the console image: %v", err)
+	}
+}
+
+// Gets the OCM token of the workspace, used as the console bearer token.
+func (cl *consoleLauncher) getOcmToken() (string, error) {
+	ce := newContainerEngine()
+	out, err := pkgIntHelper.RunCommandOutput(
+		ce.GetExecName(),
+		ce.GetExecArgs(
+			cl.workspaceContainerName,
+			cl.ocUser,
+			false,
 			"ocm",
 			"token",
-		)
+		)...,
+	)
+	if err != nil {
+		return "", fmt.Errorf("ocm token failed: %v", pkgIntHelper.CommandError(err))
+	}
+	return strings.TrimSpace(string(out)), nil
+}
+
+// Gets an OCM token that expires after the given time and its expiry (zero
+// if it is unknown). The OCM CLI only refreshes its token close to expiry so
+// this polls until it does, at most until the given time.
+func (cl *consoleLauncher) getNewOcmToken(expiry time.Time) (string, time.Time, error) {
+	for {
+		ocmToken, err := cl.getOcmToken()
 		if err != nil {
-			logger.Fatal("Failed to run command: ", err)
+			return "", time.Time{}, err
 		}
-		ocmToken := strings.TrimSpace(string(out))
-		baseAddress := fmt.Sprintf("http://127.0.0.1:%s", consoleCmdArgs.workspaceContainerPort)
-		runArgs = append(
-			runArgs,
-			consoleImage,
-			"/opt/bridge/bin/bridge",
-			"--public-dir",
-			"/opt/bridge/static",
-			"-base-address",
-			baseAddress,
-			"-branding",
-			"dedicated",
-			"-documentation-base-url",
-			"https://docs.openshift.com/dedicated/4/",
-			"-user-settings-location",
-			"localstorage",
-			"-user-auth",
-			"disabled",
-			"-k8s-mode",
-			"off-cluster",
-			"-k8s-auth",
-			"bearer-token",
-			"-k8s-mode-off-cluster-endpoint",
-			apiUrl,
-			"-k8s-mode-off-cluster-alertmanager",
-			alertManagerUrl,
-			"-k8s-mode-off-cluster-thanos",
-			thanosUrl,
-			"-k8s-auth-bearer-token",
-			ocmToken,
-			"-listen",
-			consoleListenAddr,
-			"-v",
-			"5",
-		)
-		pkgIntHelper.RunCommandWithOsFiles("podman", os.Stdout, os.Stderr, os.Stdin, runArgs...)
-	},
+		newExpiry, err := pkgIntToken.GetExpiry(ocmToken)
+		if err != nil {
+			return ocmToken, time.Time{}, nil
+		}
+		if newExpiry.After(expiry) {
+			return ocmToken, newExpiry, nil
+		}
+		if time.Now().Add(consoleTokenPollInterval).After(expiry) {
+			return "", time.Time{}, fmt.Errorf("no fresh OCM token before the bearer token expires at %v", expiry)
+		}
+		time.Sleep(consoleTokenPollInterval)
+	}
+}
+
+// Gets the time to refresh a token, nil if its expiry is unknown.
+func getRefreshTimer(expiry time.Time) <-chan time.Time {
+	if expiry.IsZero() {
+		return nil
+	}
+	return time.After(time.Until(expiry.Add(-consoleTokenRefreshMargin)))
+}
+
+func (cl *consoleLauncher) getRunArgs(ocmToken string, detach bool) (pkgInt.ContainerEngine, []string) {
+	ce := newContainerEngine()
+	consoleListenAddr := fmt.Sprintf("http://0.0.0.0:%s", cl.workspaceContainerPort)
+	baseAddress := fmt.Sprintf("http://127.0.0.1:%s", cl.workspaceContainerPort)
+
+	for _, env := range config.GetProxy(cl.ocmEnvironment).GetEnvVars() {
+		ce.AppendEnvVar(env[0], env[1])
+	}
+	ce.AppendLabel(pkgInt.ConsoleWorkspaceLabel, cl.workspaceContainerName)
+	ce.AppendLabel(pkgInt.ConsoleUrlLabel, cl.consoleUrl)
+
+	runArgs := ce.GetSidecarRunArgs(
+		cl.containerName,
+		cl.workspaceContainerName,
+		cl.consoleImage,
+		detach,
+		"/opt/bridge/bin/bridge",
+		"--public-dir",
+		"/opt/bridge/static",
+		"-base-address",
+		baseAddress,
+		"-branding",
+		"dedicated",
+		"-documentation-base-url",
+		"https://docs.openshift.com/dedicated/4/",
+		"-user-settings-location",
+		"localstorage",
+		"-user-auth",
+		"disabled",
+		"-k8s-mode",
+		"off-cluster",
+		"-k8s-auth",
+		"bearer-token",
+		"-k8s-mode-off-cluster-endpoint",
+		cl.apiUrl,
+		"-k8s-mode-off-cluster-alertmanager",
+		cl.alertManagerUrl,
+		"-k8s-mode-off-cluster-thanos",
+		cl.thanosUrl,
+		"-k8s-auth-bearer-token",
+		ocmToken,
+		"-listen",
+		consoleListenAddr,
+		"-v",
+		"5",
+	)
+	logger.Debugf("Console container run command: %v", pkgIntHelper.RedactArgs(runArgs, ocmToken))
+	return ce, runArgs
+}
+
+// Runs the console container in the foreground and restarts it with a fresh
+// bearer token before the token expires.
+func (cl *consoleLauncher) runForeground() {
+	ocmToken, err := cl.getOcmToken()
+	if err != nil {
+		logger.Fatal("Failed to get the bearer token: ", err)
+	}
+
+	for {
+		expiry, err := pkgIntToken.GetExpiry(ocmToken)
+		if err != nil {
+			logger.Warnf("Bearer token will not be refreshed, failed to get its expiry: %v", err)
+		}
+
+		ce, runArgs := cl.getRunArgs(ocmToken, false)
+		done := make(chan error, 1)
+		go func() {
+			done <- pkgIntHelper.RunCommandWithOsFiles(ce.GetExecName(), os.Stdout, os.Stderr, os.Stdin, runArgs...)
+		}()
+
+		select {
+		case <-done:
+			return
+		case <-getRefreshTimer(expiry):
+			ocmToken, expiry, err = cl.getNewOcmToken(expiry)
+			cl.stop()
+			<-done
+			if err != nil {
+				logger.Fatal("Stopped the OpenShift console, failed to refresh its bearer token: ", err)
+			}
+			logger.Infof("Restarting the OpenShift console with a fresh bearer token that expires at %v", expiry)
+		}
+	}
+}
+
+// Runs the console container in the background and returns the expiry of
+// its bearer token.
+func (cl *consoleLauncher) runDetached() time.Time {
+	ocmToken, err := cl.getOcmToken()
+	if err != nil {
+		logger.Fatal("Failed to get the bearer token: ", err)
+	}
+	expiry, err := pkgIntToken.GetExpiry(ocmToken)
+	if err != nil {
+		logger.Warnf("Bearer token will not be refreshed, failed to get its expiry: %v", err)
+	}
+	cl.runDetachedWithToken(ocmToken)
+	return expiry
+}
+
+func (cl *consoleLauncher) runDetachedWithToken(ocmToken string) {
+	ce, runArgs := cl.getRunArgs(ocmToken, true)
+	_, err := pkgIntHelper.RunCommandOutput(ce.GetExecName(), runArgs...)
+	if err != nil {
+		logger.Fatal("Failed to run OpenShift console container: ", err)
+	}
+}
+
+// Gets the container ID of the running console.
+func (cl *consoleLauncher) getConsoleID() string {
+	consoles, err := pkgInt.ListConsoles(newContainerEngine())
+	if err != nil {
+		logger.Fatal("Failed to list OpenShift consoles: ", err)
+	}
+	console := pkgInt.FindConsole(consoles, cl.workspaceContainerName)
+	if console == nil {
+		logger.Fatalf("OpenShift console of %s is not running", cl.workspaceContainerName)
+	}
+	return console.ID
+}
+
+// Stops the console container and waits until it is removed.
+func (cl *consoleLauncher) stop() {
+	ce := newContainerEngine()
+	_, err := pkgIntHelper.RunCommandOutput(ce.GetExecName(), ce.GetStopArgs(cl.containerName)...)
+	if err != nil {
+		logger.Errorf("Failed to stop OpenShift console container %s: %v", cl.containerName, err)
+	}
+
+	for attempt := 0; attempt < 30; attempt++ {
+		_, err = pkgIntHelper.RunCommandOutput(ce.GetExecName(), ce.GetInspectArgs(cl.containerName)...)
+		if err != nil {
+			return
+		}
+		time.Sleep(time.Second)
+	}
+}
+
+// Starts a background "openshiftConsole refresh" process that restarts the
+// detached console with a fresh bearer token before the token expires. A
+// refresher left over from a previous console of the workspace is stopped.
+func (cl *consoleLauncher) startRefresher(expiry time.Time, consoleID string) {
+	stopConsoleRefresher(cl.workspaceContainerName)
+	if expiry.IsZero() {
+		return
+	}
+
+	executable, err := os.Executable()
+	if err != nil {
+		logger.Fatal("Failed to get the workspace executable: ", err)
+	}
+
+	stateDir, err := pkgInt.GetStateDir()
+	if err != nil {
+		logger.Fatal("Failed to get the state directory: ", err)
+	}
+	logPath := fmt.Sprintf("%s/%s.log", stateDir, cl.containerName)
+
+	refreshArgs := []string{
+		"openshiftConsole",
+		"refresh",
+		cl.workspaceContainerName,
+		"--workspaceContainerPort",
+		cl.workspaceContainerPort,
+		"--ocmEnvironment",
+		cl.ocmEnvironment,
+		"--expiresAt",
+		expiry.Format(time.RFC3339),
+		"--consoleContainerId",
+		consoleID,
+		"--engine",
+		config.GetContainerEngine(),
+	}
+	if configFile := viper.ConfigFileUsed(); len(configFile) > 0 {
+		refreshArgs = append(refreshArgs, "--config", configFile)
+	}
+	if len(profile) > 0 {
+		refreshArgs = append(refreshArgs, "--profile", profile)
+	}
+
+	err = pkgIntHelper.RunCommandDetached(executable, refreshArgs, logPath)
+	if err != nil {
+		logger.Errorf("Failed to start the bearer token refresher: %v", err)
+		return
+	}
+	logger.Debugf("Bearer token refresher started, logging to %s", logPath)
+}
+
+// Gets the name of the PID file of the bearer token refresher of a
+// workspace's console.
+func getConsoleRefresherPidName(workspaceContainerName string) string {
+	return fmt.Sprintf("%s.refresh", pkgInt.GetConsoleContainerName(workspaceContainerName))
+}
+
+// Stops the bearer token refresher of a workspace's console, if any.
+func stopConsoleRefresher(workspaceContainerName string) {
+	err := pkgInt.TerminatePidFile(
+		getConsoleRefresherPidName(workspaceContainerName),
+		"refresh",
+		workspaceContainerName,
+	)
+	if err != nil {
+		logger.Warnf("Failed to stop the bearer token refresher of %s: %v", workspaceContainerName, err)
+	}
+}
+
+// Gets the workspace container name, console port and OCM environment from
+// the flags, or discovers them from the running workspace containers.
+func resolveConsoleWorkspace(ce pkgInt.ContainerEngine, args []string) (string, string, string) {
+	name := consoleCmdArgs.workspaceContainerName
+	port := consoleCmdArgs.workspaceContainerPort
+	if len(name) > 0 && len(port) > 0 {
+		return name, port, ""
+	}
+
+	ref := name
+	if len(ref) == 0 && len(args) > 0 {
+		ref = args[0]
+	}
+	ws := resolveWorkspaces(ce, []string{ref}, false, false)[0]
+
+	if len(port) == 0 {
+		port = ws.ConsolePort
+	}
+	if len(port) == 0 {
+		logger.Fatalf("Failed to find the OpenShift console port of workspace %s, use --workspaceContainerPort", ws.Name)
+	}
+	return ws.Name, port, ws.OcmEnvironment
+}
+
+// Opens a URL in the browser (xdg-open) once it responds.
+func openWhenAvailable(url string) {
+	client := http.Client{Timeout: 2 * time.Second}
+	for attempt := 0; attempt < 60; attempt++ {
+		resp, err := client.Get(url)
+		if err == nil {
+			resp.Body.Close()
+			err = pkgIntHelper.RunCommandBackground("xdg-open", []string{url}, nil)
+			if err != nil {
+				logger.Errorf("Failed to open %s: %v", url, err)
+			}
+			return
+		}
+		time.Sleep(time.Second)
+	}
+	logger.Errorf("OpenShift console at %s is not available", url)
 }
 
 func init() {
@@ -227,7 +592,7 @@ func init() {
 		"workspaceContainerName",
 		"c",
 		"",
-		"The running workspace container name that is logged into an OpenShift cluster.",
+		"The running workspace container name that is logged into an OpenShift cluster (default is discovered).",
 	)
 
 	flags.StringVarP(
@@ -235,9 +600,20 @@ func init() {
 		"workspaceContainerPort",
 		"p",
 		"",
-		"The running workspace container port that is logged into an OpenShift cluster.",
+		"The running workspace container port that is logged into an OpenShift cluster (default is discovered).",
+	)
+
+	flags.BoolVar(
+		&consoleCmdArgs.open,
+		"open",
+		false,
+		"Open the OpenShift console in the browser using xdg-open.",
 	)
 
-	openshiftConsoleCmd.MarkFlagRequired("workspaceContainerName")
-	openshiftConsoleCmd.MarkFlagRequired("workspaceContainerPort")
+	flags.BoolVar(
+		&consoleCmdArgs.detach,
+		"detach",
+		false,
+		"Run the OpenShift console container in the background.",
+	)
 }
@@ -0,0 +1,86 @@
+/*
+Copyright © 2023 Jose Cueto
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+package cmd
+
+import (
+	"fmt"
+	"os"
+	"text/tabwriter"
+	"time"
+
+	logger "github.com/sirupsen/logrus"
+	"github.com/spf13/cobra"
+
+	pkgInt "ocm-workspace/internal"
+	pkgIntHelper "ocm-workspace/internal/helpers"
+)
+
+var (
+	consoleImagesCmdArgs struct {
+		output string
+	}
+)
+
+var openshiftConsoleImagesCmd = &cobra.Command{
+	Use:    "images",
+	Short:  "Lists the cached OpenShift console images.",
+	Long:   `Lists the OpenShift console images pulled by the openshiftConsole command and the OpenShift versions they are used for.`,
+	Args:   cobra.NoArgs,
+	PreRun: toggleDebug,
+	Run:    onOpenshiftConsoleImages,
+}
+
+func onOpenshiftConsoleImages(cmd *cobra.Command, args []string) {
+	cache, err := pkgInt.NewConsoleImageCache()
+	if err != nil {
+		logger.Fatal("Failed to load the console image cache: ", err)
+	}
+	images := cache.GetImages()
+
+	if len(consoleImagesCmdArgs.output) > 0 {
+		err = printStructured(consoleImagesCmdArgs.output, images)
+		if err != nil {
+			logger.Fatal(err)
+		}
+		return
+	}
+
+	w := tabwriter.NewWriter(os.Stdout, 0, 0, 3, ' ', 0)
+	fmt.Fprintln(w, "OPENSHIFT VERSION\tLAST USED\tDIGEST\tIMAGE")
+	for _, img := range images {
+		fmt.Fprintf(
+			w,
+			"%s\t%s\t%s\t%s\n",
+			orNone(img.OpenShiftVersion),
+			pkgIntHelper.FormatDuration(time.Since(img.LastUsed)),
+			orNone(img.Digest),
+			img.Image,
+		)
+	}
+	w.Flush()
+}
+
+func init() {
+	openshiftConsoleCmd.AddCommand(openshiftConsoleImagesCmd)
+
+	openshiftConsoleImagesCmd.Flags().StringVarP(
+		&consoleImagesCmdArgs.output,
+		"output",
+		"o",
+		"",
+		"Output format (json, yaml).",
+	)
+}
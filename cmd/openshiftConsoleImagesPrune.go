@@ -0,0 +1,91 @@
+/*
+Copyright © 2023 Jose Cueto
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+package cmd
+
+import (
+	"time"
+
+	logger "github.com/sirupsen/logrus"
+	"github.com/spf13/cobra"
+
+	pkgInt "ocm-workspace/internal"
+	pkgIntHelper "ocm-workspace/internal/helpers"
+)
+
+var (
+	consoleImagesPruneCmdArgs struct {
+		olderThan time.Duration
+	}
+)
+
+var openshiftConsoleImagesPruneCmd = &cobra.Command{
+	Use:         "prune",
+	Short:       "Removes cached OpenShift console images.",
+	Long:        `Removes the cached OpenShift console images that are not used by a running console and were last used before --older-than.`,
+	Args:        cobra.NoArgs,
+	Annotations: map[string]string{skipValidationAnnotation: "true"},
+	PreRun:      toggleDebug,
+	Run:         onOpenshiftConsoleImagesPrune,
+}
+
+func onOpenshiftConsoleImagesPrune(cmd *cobra.Command, args []string) {
+	ce := newContainerEngine()
+	cache, err := pkgInt.NewConsoleImageCache()
+	if err != nil {
+		logger.Fatal("Failed to load the console image cache: ", err)
+	}
+
+	consoles, err := pkgInt.ListConsoles(ce)
+	if err != nil {
+		logger.Fatal("Failed to list OpenShift consoles: ", err)
+	}
+	inUse := map[string]bool{}
+	for _, console := range consoles {
+		inUse[console.Image] = true
+	}
+
+	for _, img := range cache.GetImages() {
+		if inUse[img.Image] || time.Since(img.LastUsed) < consoleImagesPruneCmdArgs.olderThan {
+			continue
+		}
+
+		_, err = pkgIntHelper.RunCommandOutput(ce.GetExecName(), ce.GetImageRmArgs(img.Image)...)
+		if err != nil {
+			// The image may have been removed outside of the workspace
+			if _, inspectErr := pkgInt.GetLocalImageDigest(ce, img.Image); inspectErr == nil {
+				logger.Errorf("Failed to remove console image %s: %v", img.Image, err)
+				continue
+			}
+		}
+
+		err = cache.Remove(img.Image)
+		if err != nil {
+			logger.Fatal("Failed to update the console image cache: ", err)
+		}
+		logger.Infof("Removed console image %s (OpenShift %s)", img.Image, img.OpenShiftVersion)
+	}
+}
+
+func init() {
+	openshiftConsoleImagesCmd.AddCommand(openshiftConsoleImagesPruneCmd)
+
+	openshiftConsoleImagesPruneCmd.Flags().DurationVar(
+		&consoleImagesPruneCmdArgs.olderThan,
+		"older-than",
+		7*24*time.Hour,
+		"Only remove images last used before this duration ago.",
+	)
+}
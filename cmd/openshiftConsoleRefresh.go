@@ -0,0 +1,129 @@
+/*
+Copyright © 2023 Jose Cueto
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+package cmd
+
+import (
+	"time"
+
+	logger "github.com/sirupsen/logrus"
+	"github.com/spf13/cobra"
+
+	pkgInt "ocm-workspace/internal"
+)
+
+var (
+	consoleRefreshCmdArgs struct {
+		workspaceContainerPort string
+		ocmEnvironment         string
+		expiresAt              string
+		consoleContainerId     string
+	}
+)
+
+// openshiftConsoleRefreshCmd is started in the background by
+// "openshiftConsole --detach" to keep the console's bearer token fresh.
+var openshiftConsoleRefreshCmd = &cobra.Command{
+	Use:    "refresh <container>",
+	Short:  "Restarts a detached OpenShift console before its bearer token expires.",
+	Hidden: true,
+	Args:   cobra.ExactArgs(1),
+	PreRun: toggleDebug,
+	Run:    onOpenshiftConsoleRefresh,
+}
+
+func onOpenshiftConsoleRefresh(cmd *cobra.Command, args []string) {
+	expiry, err := time.Parse(time.RFC3339, consoleRefreshCmdArgs.expiresAt)
+	if err != nil {
+		logger.Fatal("Failed to parse the bearer token expiry: ", err)
+	}
+
+	launcher := newConsoleLauncher(args[0], consoleRefreshCmdArgs.workspaceContainerPort, consoleRefreshCmdArgs.ocmEnvironment)
+	pidName := getConsoleRefresherPidName(launcher.workspaceContainerName)
+	err = pkgInt.WritePidFile(pidName)
+	if err != nil {
+		logger.Fatal("Failed to record the refresher PID: ", err)
+	}
+	defer pkgInt.RemovePidFile(pidName)
+
+	consoleID := consoleRefreshCmdArgs.consoleContainerId
+	for !expiry.IsZero() {
+		time.Sleep(time.Until(expiry.Add(-consoleTokenRefreshMargin)))
+
+		// The console may have been stopped and started again with its own
+		// refresher
+		consoles, err := pkgInt.ListConsoles(newContainerEngine())
+		if err != nil {
+			logger.Fatal("Failed to list OpenShift consoles: ", err)
+		}
+		console := pkgInt.FindConsole(consoles, launcher.workspaceContainerName)
+		if console == nil || console.ID != consoleID {
+			logger.Infof("OpenShift console %s of %s is no longer running", consoleID, launcher.workspaceContainerName)
+			return
+		}
+
+		// Restart the console with the same image and cluster URLs
+		launcher.consoleImage = console.Image
+		launcher.loadClusterUrls(newContainerEngine())
+
+		ocmToken, newExpiry, err := launcher.getNewOcmToken(expiry)
+		launcher.stop()
+		if err != nil {
+			logger.Fatal("Stopped the OpenShift console, failed to refresh its bearer token: ", err)
+		}
+		logger.Infof("Restarting the OpenShift console with a fresh bearer token that expires at %v", newExpiry)
+		launcher.runDetachedWithToken(ocmToken)
+		consoleID = launcher.getConsoleID()
+		expiry = newExpiry
+	}
+	logger.Warn("Bearer token will not be refreshed, its expiry is unknown")
+}
+
+func init() {
+	openshiftConsoleCmd.AddCommand(openshiftConsoleRefreshCmd)
+
+	flags := openshiftConsoleRefreshCmd.Flags()
+	flags.StringVar(
+		&consoleRefreshCmdArgs.workspaceContainerPort,
+		"workspaceContainerPort",
+		"",
+		"The workspace container port of the OpenShift console.",
+	)
+
+	flags.StringVar(
+		&consoleRefreshCmdArgs.ocmEnvironment,
+		"ocmEnvironment",
+		"",
+		"The OCM environment of the workspace.",
+	)
+
+	flags.StringVar(
+		&consoleRefreshCmdArgs.expiresAt,
+		"expiresAt",
+		"",
+		"The expiry time (RFC3339) of the console's current bearer token.",
+	)
+
+	flags.StringVar(
+		&consoleRefreshCmdArgs.consoleContainerId,
+		"consoleContainerId",
+		"",
+		"The container ID of the console, the refresher exits when it changes.",
+	)
+
+	openshiftConsoleRefreshCmd.MarkFlagRequired("workspaceContainerPort")
+	openshiftConsoleRefreshCmd.MarkFlagRequired("expiresAt")
+	openshiftConsoleRefreshCmd.MarkFlagRequired("consoleContainerId")
+}
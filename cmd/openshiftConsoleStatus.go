@@ -0,0 +1,97 @@
+/*
+Copyright © 2023 Jose Cueto
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+package cmd
+
+import (
+	"fmt"
+	"os"
+	"text/tabwriter"
+	"time"
+
+	logger "github.com/sirupsen/logrus"
+	"github.com/spf13/cobra"
+
+	pkgInt "ocm-workspace/internal"
+	pkgIntHelper "ocm-workspace/internal/helpers"
+)
+
+var (
+	consoleStatusCmdArgs struct {
+		output string
+	}
+)
+
+var openshiftConsoleStatusCmd = &cobra.Command{
+	Use:         "status [cluster or container]",
+	Short:       "Shows the running OpenShift consoles.",
+	Long:        `Shows the running OpenShift consoles with their URL and image, optionally only the console of a workspace.`,
+	Args:        cobra.MaximumNArgs(1),
+	Annotations: map[string]string{skipValidationAnnotation: "true"},
+	PreRun:      toggleDebug,
+	Run:         onOpenshiftConsoleStatus,
+}
+
+func onOpenshiftConsoleStatus(cmd *cobra.Command, args []string) {
+	ce := newContainerEngine()
+	consoles, err := pkgInt.ListConsoles(ce)
+	if err != nil {
+		logger.Fatal("Failed to list OpenShift consoles: ", err)
+	}
+
+	if len(args) > 0 {
+		ws := resolveWorkspaces(ce, args, false, false)[0]
+		console := pkgInt.FindConsole(consoles, ws.Name)
+		if console == nil {
+			logger.Fatalf("No OpenShift console is running for workspace %s", ws.Name)
+		}
+		consoles = []pkgInt.Console{*console}
+	}
+
+	if len(consoleStatusCmdArgs.output) > 0 {
+		err = printStructured(consoleStatusCmdArgs.output, consoles)
+		if err != nil {
+			logger.Fatal(err)
+		}
+		return
+	}
+
+	w := tabwriter.NewWriter(os.Stdout, 0, 0, 3, ' ', 0)
+	fmt.Fprintln(w, "WORKSPACE\tURL\tSTATUS\tAGE\tIMAGE")
+	for _, console := range consoles {
+		fmt.Fprintf(
+			w,
+			"%s\t%s\t%s\t%s\t%s\n",
+			console.Workspace,
+			orNone(console.Url),
+			console.Status,
+			pkgIntHelper.FormatDuration(time.Since(console.Created)),
+			console.Image,
+		)
+	}
+	w.Flush()
+}
+
+func init() {
+	openshiftConsoleCmd.AddCommand(openshiftConsoleStatusCmd)
+
+	openshiftConsoleStatusCmd.Flags().StringVarP(
+		&consoleStatusCmdArgs.output,
+		"output",
+		"o",
+		"",
+		"Output format (json, yaml).",
+	)
+}
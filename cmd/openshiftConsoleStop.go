@@ -0,0 +1,63 @@
+/*
+Copyright © 2023 Jose Cueto
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+package cmd
+
+import (
+	logger "github.com/sirupsen/logrus"
+	"github.com/spf13/cobra"
+
+	pkgInt "ocm-workspace/internal"
+	pkgIntHelper "ocm-workspace/internal/helpers"
+)
+
+var openshiftConsoleStopCmd = &cobra.Command{
+	Use:         "stop [cluster or container]",
+	Short:       "Stops the OpenShift console of a workspace.",
+	Args:        cobra.MaximumNArgs(1),
+	Annotations: map[string]string{skipValidationAnnotation: "true"},
+	PreRun:      toggleDebug,
+	Run:         onOpenshiftConsoleStop,
+}
+
+func onOpenshiftConsoleStop(cmd *cobra.Command, args []string) {
+	ce := newContainerEngine()
+	consoles, err := pkgInt.ListConsoles(ce)
+	if err != nil {
+		logger.Fatal("Failed to list OpenShift consoles: ", err)
+	}
+
+	var console *pkgInt.Console
+	if len(args) == 0 && len(consoles) == 1 {
+		console = &consoles[0]
+	} else {
+		ws := resolveWorkspaces(ce, args, false, false)[0]
+		console = pkgInt.FindConsole(consoles, ws.Name)
+		if console == nil {
+			logger.Fatalf("No OpenShift console is running for workspace %s", ws.Name)
+		}
+	}
+
+	stopConsoleRefresher(console.Workspace)
+	_, err = pkgIntHelper.RunCommandOutput(ce.GetExecName(), ce.GetStopArgs(console.Name)...)
+	if err != nil {
+		logger.Fatalf("Failed to stop OpenShift console %s: %v", console.Name, err)
+	}
+	logger.Infof("Stopped OpenShift console of %s", console.Workspace)
+}
+
+func init() {
+	openshiftConsoleCmd.AddCommand(openshiftConsoleStopCmd)
+}
@@ -0,0 +1,103 @@
+/*
+Copyright © 2023 Jose Cueto
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+package cmd
+
+import (
+	logger "github.com/sirupsen/logrus"
+	"github.com/spf13/cobra"
+
+	pkgInt "ocm-workspace/internal"
+)
+
+var (
+	pluginsInstallCmdArgs struct {
+		sha256   string
+		commit   string
+		insecure bool
+		force    bool
+	}
+)
+
+var pluginsInstallCmd = &cobra.Command{
+	Use:   "install <source>",
+	Short: "Installs a plugin into the plugins directory.",
+	Long: `Installs a plugin with a plugin.yaml manifest into ~/.ocm-workspace/plugins/<plugin name>. The source is
+one of the following.
+
+  - a local directory
+  - a tarball (.tar, .tar.gz or .tgz) path or http(s) URL
+  - a git repository (git+<url> or a URL ending with .git), optionally with #<tag or branch>
+  - an OCI image holding the plugin in /plugin (oci://<image>), pulled with the container engine
+
+Remote tarballs and OCI images are verified by their SHA-256 checksum or digest (--sha256 or an image
+reference with @sha256:<digest>) and git sources by their commit ID (--commit), unless --insecure is set.
+Plugins that ship Go source (a go.mod) without their executable are built with go build.`,
+	Example: `  workspace plugins install ./portForward
+  workspace plugins install https://example.com/portForward-1.0.0.tar.gz --sha256 <checksum>
+  workspace plugins install git+https://github.com/example/portForward#v1.0.0 --commit <commit ID>
+  workspace plugins install oci://quay.io/example/portforward:1.0.0 --sha256 <digest>`,
+	Args: cobra.ExactArgs(1),
+	// The config may reference the plugin that is not installed yet
+	Annotations: map[string]string{skipValidationAnnotation: "true"},
+	PreRun:      toggleDebug,
+	Run: func(cmd *cobra.Command, args []string) {
+		opts := pkgInt.PluginInstallOptions{
+			Sha256:   pluginsInstallCmdArgs.sha256,
+			Commit:   pluginsInstallCmdArgs.commit,
+			Insecure: pluginsInstallCmdArgs.insecure,
+			Force:    pluginsInstallCmdArgs.force,
+		}
+		if kind, _, err := pkgInt.GetPluginSourceKind(args[0]); err == nil && kind == pkgInt.PluginSourceOci {
+			opts.Ce = newContainerEngine()
+		}
+
+		manifest, err := pkgInt.InstallPlugin(args[0], opts)
+		if err != nil {
+			logger.Fatal("Failed to install the plugin: ", err)
+		}
+		logger.Infof("Installed plugin %s %s in %s", manifest.Name, manifest.Version, manifest.Dir)
+	},
+}
+
+func init() {
+	pluginsCmd.AddCommand(pluginsInstallCmd)
+
+	flags := pluginsInstallCmd.Flags()
+	flags.StringVar(
+		&pluginsInstallCmdArgs.sha256,
+		"sha256",
+		"",
+		"Expected SHA-256 checksum of a tarball or digest of an OCI image.",
+	)
+	flags.StringVar(
+		&pluginsInstallCmdArgs.commit,
+		"commit",
+		"",
+		"Expected commit ID of a git source.",
+	)
+	flags.BoolVar(
+		&pluginsInstallCmdArgs.insecure,
+		"insecure",
+		false,
+		"Install a remote tarball, git source or OCI image without verifying it.",
+	)
+	flags.BoolVar(
+		&pluginsInstallCmdArgs.force,
+		"force",
+		false,
+		"Replace an installed plugin of the same name.",
+	)
+}
@@ -0,0 +1,86 @@
+/*
+Copyright © 2023 Jose Cueto
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+package cmd
+
+import (
+	"fmt"
+	"os"
+	"strconv"
+	"strings"
+	"text/tabwriter"
+
+	logger "github.com/sirupsen/logrus"
+	"github.com/spf13/cobra"
+
+	pkgInt "ocm-workspace/internal"
+)
+
+var (
+	pluginsListCmdArgs struct {
+		output string
+	}
+)
+
+var pluginsListCmd = &cobra.Command{
+	Use:   "list",
+	Short: "Lists the installed plugins.",
+	Long: `Lists the plugins installed in ~/.ocm-workspace/plugins with the commands, hook events and ports of
+their plugin.yaml manifest. Plugins with an invalid manifest are reported as warnings.`,
+	Args:        cobra.NoArgs,
+	Annotations: map[string]string{skipConfigAnnotation: "true"},
+	PreRun:      toggleDebug,
+	Run: func(cmd *cobra.Command, args []string) {
+		manifests, errs := pkgInt.ListInstalledPlugins()
+		for _, err := range errs {
+			logger.Warn(err)
+		}
+
+		if len(pluginsListCmdArgs.output) > 0 {
+			err := printStructured(pluginsListCmdArgs.output, manifests)
+			if err != nil {
+				logger.Fatal(err)
+			}
+			return
+		}
+
+		w := tabwriter.NewWriter(os.Stdout, 0, 0, 3, ' ', 0)
+		fmt.Fprintln(w, "NAME\tVERSION\tCOMMANDS\tHOOKS\tPORTS\tDESCRIPTION")
+		for _, manifest := range manifests {
+			fmt.Fprintf(
+				w,
+				"%s\t%s\t%s\t%s\t%s\t%s\n",
+				manifest.Name,
+				manifest.Version,
+				strings.Join(manifest.Commands, ","),
+				orNone(strings.Join(manifest.Hooks, ",")),
+				strconv.Itoa(manifest.Ports),
+				orNone(manifest.Description),
+			)
+		}
+		w.Flush()
+	},
+}
+
+func init() {
+	pluginsCmd.AddCommand(pluginsListCmd)
+	pluginsListCmd.Flags().StringVarP(
+		&pluginsListCmdArgs.output,
+		"output",
+		"o",
+		"",
+		"Output format (json, yaml).",
+	)
+}
@@ -0,0 +1,97 @@
+/*
+Copyright © 2023 Jose Cueto
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+package cmd
+
+import (
+	"fmt"
+	"os"
+	"strconv"
+	"text/tabwriter"
+	"time"
+
+	logger "github.com/sirupsen/logrus"
+	"github.com/spf13/cobra"
+
+	pkgInt "ocm-workspace/internal"
+	pkgIntHelper "ocm-workspace/internal/helpers"
+)
+
+var (
+	pluginsStatusCmdArgs struct {
+		output string
+	}
+)
+
+var pluginsStatusCmd = &cobra.Command{
+	Use:   "status",
+	Short: "Shows the state of the background plugins in the workspace container.",
+	Long: `Shows the state, PID, restarts and log file of the plugins started in the background by the
+workspace login. Run it from a workspace shell.`,
+	Args:   cobra.NoArgs,
+	PreRun: toggleDebug,
+	Run: func(cmd *cobra.Command, args []string) {
+		if err := checkContainerCommand(); err != nil {
+			logger.Fatal(err)
+		}
+
+		statuses, err := pkgInt.LoadPluginStatus(pkgInt.ContainerPluginStateDir)
+		if err != nil {
+			logger.Fatal("Failed to load the plugin states: ", err)
+		}
+
+		if len(pluginsStatusCmdArgs.output) > 0 {
+			err = printStructured(pluginsStatusCmdArgs.output, statuses)
+			if err != nil {
+				logger.Fatal(err)
+			}
+			return
+		}
+
+		w := tabwriter.NewWriter(os.Stdout, 0, 0, 3, ' ', 0)
+		fmt.Fprintln(w, "NAME\tSTATE\tPID\tRESTARTS\tRESTART POLICY\tUPTIME\tLOG")
+		for _, status := range statuses {
+			pid := ""
+			uptime := ""
+			if status.Pid > 0 {
+				pid = strconv.Itoa(status.Pid)
+				uptime = pkgIntHelper.FormatDuration(time.Since(status.StartedAt))
+			}
+			fmt.Fprintf(
+				w,
+				"%s\t%s\t%s\t%d\t%s\t%s\t%s\n",
+				status.Name,
+				status.State,
+				orNone(pid),
+				status.Restarts,
+				status.RestartPolicy,
+				orNone(uptime),
+				status.LogPath,
+			)
+		}
+		w.Flush()
+	},
+}
+
+func init() {
+	pluginsCmd.AddCommand(pluginsStatusCmd)
+	pluginsStatusCmd.Flags().StringVarP(
+		&pluginsStatusCmdArgs.output,
+		"output",
+		"o",
+		"",
+		"Output format (json, yaml).",
+	)
+}
@@ -0,0 +1,101 @@
+/*
+Copyright © 2023 Jose Cueto
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+package cmd
+
+import (
+	"time"
+
+	logger "github.com/sirupsen/logrus"
+	"github.com/spf13/cobra"
+
+	pkgInt "ocm-workspace/internal"
+)
+
+var (
+	pruneCmdArgs struct {
+		olderThan time.Duration
+	}
+)
+
+var pruneCmd = &cobra.Command{
+	Use:   "prune",
+	Short: "Removes stopped workspace containers.",
+	Long: `Removes stopped workspace containers, optionally only the ones older than a duration (e.g. --older-than 24h).
+Port leases and secrets of workspace containers that no longer exist are released as well.`,
+	Args:        cobra.NoArgs,
+	Annotations: map[string]string{skipValidationAnnotation: "true"},
+	PreRun:      toggleDebug,
+	Run:         onPrune,
+}
+
+// Time a login has to create the container of its port leases
+const orphanLeaseGracePeriod = 5 * time.Minute
+
+func onPrune(cmd *cobra.Command, args []string) {
+	ce := newContainerEngine()
+	workspaces, err := pkgInt.ListWorkspaces(ce, true)
+	if err != nil {
+		logger.Fatal("Failed to list workspaces: ", err)
+	}
+
+	portLeases, err := pkgInt.NewPortLeases()
+	if err != nil {
+		logger.Fatal("Failed to load port leases: ", err)
+	}
+	defer portLeases.Close()
+
+	existing := map[string]bool{}
+	for _, ws := range workspaces {
+		if ws.Running || time.Since(ws.Created) < pruneCmdArgs.olderThan {
+			existing[ws.Name] = true
+			continue
+		}
+
+		err = removeWorkspace(ce, portLeases, &ws)
+		if err != nil {
+			logger.Fatalf("Failed to remove workspace %s: %v", ws.Name, err)
+		}
+		logger.Infof("Removed workspace %s", ws.Name)
+	}
+
+	// Release the port leases and secrets of containers removed outside of the
+	// workspace
+	orphans := portLeases.GetOrphans(existing, orphanLeaseGracePeriod)
+	if len(orphans) > 0 {
+		logger.Debugf("Releasing port leases of %v", orphans)
+		err = portLeases.Release(orphans...)
+		if err != nil {
+			logger.Fatal("Failed to release port leases: ", err)
+		}
+		for _, orphan := range orphans {
+			err = pkgInt.RemoveWorkspaceSecrets(orphan)
+			if err != nil {
+				logger.Errorf("Failed to remove the secrets of %s: %v", orphan, err)
+			}
+		}
+	}
+}
+
+func init() {
+	rootCmd.AddCommand(pruneCmd)
+
+	pruneCmd.Flags().DurationVar(
+		&pruneCmdArgs.olderThan,
+		"older-than",
+		0,
+		"Only remove workspaces created before this duration ago (e.g. 24h).",
+	)
+}
@@ -0,0 +1,110 @@
+/*
+Copyright © 2023 Jose Cueto
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+package cmd
+
+import (
+	logger "github.com/sirupsen/logrus"
+	"github.com/spf13/cobra"
+
+	pkgInt "ocm-workspace/internal"
+	pkgIntHelper "ocm-workspace/internal/helpers"
+)
+
+var (
+	rmCmdArgs struct {
+		all   bool
+		force bool
+	}
+)
+
+var rmCmd = &cobra.Command{
+	Use:         "rm [cluster or container]...",
+	Short:       "Removes workspace containers.",
+	Long:        `Removes workspace containers and releases their allocated host ports. Running workspaces are only removed with --force.`,
+	Annotations: map[string]string{skipValidationAnnotation: "true"},
+	PreRun:      toggleDebug,
+	Run:         onRm,
+}
+
+func onRm(cmd *cobra.Command, args []string) {
+	ce := newContainerEngine()
+	workspaces := resolveWorkspaces(ce, args, rmCmdArgs.all, true)
+
+	portLeases, err := pkgInt.NewPortLeases()
+	if err != nil {
+		logger.Fatal("Failed to load port leases: ", err)
+	}
+	defer portLeases.Close()
+
+	for _, ws := range workspaces {
+		if ws.Running && !rmCmdArgs.force {
+			logger.Fatalf("Workspace %s is running, stop it first or use --force", ws.Name)
+		}
+
+		err = removeWorkspace(ce, portLeases, &ws)
+		if err != nil {
+			logger.Fatalf("Failed to remove workspace %s: %v", ws.Name, err)
+		}
+		logger.Infof("Removed workspace %s", ws.Name)
+	}
+}
+
+// Stops and removes a workspace container and its console container, then
+// removes its secrets and releases its leased host ports.
+func removeWorkspace(ce pkgInt.ContainerEngine, portLeases *pkgInt.PortLeases, ws *pkgInt.Workspace) error {
+	err := stopWorkspace(ce, ws)
+	if err != nil {
+		return err
+	}
+
+	consoleContainerName := pkgInt.GetConsoleContainerName(ws.Name)
+	_, err = pkgIntHelper.RunCommandOutput(ce.GetExecName(), ce.GetRmArgs(true, consoleContainerName)...)
+	if err != nil {
+		logger.Debugf("No console container %s removed: %v", consoleContainerName, err)
+	}
+
+	_, err = pkgIntHelper.RunCommandOutput(ce.GetExecName(), ce.GetRmArgs(true, ws.Name)...)
+	if err != nil {
+		return err
+	}
+
+	err = pkgInt.RemoveWorkspaceSecrets(ws.Name)
+	if err != nil {
+		return err
+	}
+	return portLeases.Release(ws.Name)
+}
+
+func init() {
+	rootCmd.AddCommand(rmCmd)
+
+	flags := rmCmd.Flags()
+	flags.BoolVarP(
+		&rmCmdArgs.all,
+		"all",
+		"a",
+		false,
+		"Remove all workspaces.",
+	)
+
+	flags.BoolVarP(
+		&rmCmdArgs.force,
+		"force",
+		"f",
+		false,
+		"Stop and remove running workspaces.",
+	)
+}
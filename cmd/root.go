@@ -19,6 +19,7 @@ import (
 	"os"
 
 	"github.com/golang/glog"
+	logger "github.com/sirupsen/logrus"
 	"github.com/spf13/cobra"
 
 	"github.com/spf13/viper"
@@ -29,9 +30,45 @@ import (
 var config *pkgInt.OcmWorkspaceConfig
 var cfgFile string
 
+// The merged config files and profile with the origins of their values
+var configSources *pkgInt.ConfigSources
+
+// The config profile selected by --profile or OCM_WORKSPACE_PROFILE
+var profile string
+
+// Commands annotated with skipConfigAnnotation run without a config file,
+// those annotated with skipValidationAnnotation with an invalid one. The
+// listing and teardown commands (e.g. stop, rm, prune) skip the validation,
+// so that an unmounted dir map or an uninstalled plugin does not block them.
+const (
+	skipConfigAnnotation     = "ocm-workspace/skip-config"
+	skipValidationAnnotation = "ocm-workspace/skip-validation"
+)
+
 var rootCmd = &cobra.Command{
 	Use:   "workspace",
 	Short: "A containerised workspace for managing OpenShift Dedicated",
+	PersistentPreRun: func(cmd *cobra.Command, args []string) {
+		if cmd.Annotations[skipConfigAnnotation] == "true" {
+			return
+		}
+		initConfig()
+
+		// Paths in the config are host paths
+		if isInContainer() {
+			return
+		}
+		config.ResolvePlugins()
+		if cmd.Annotations[skipValidationAnnotation] == "true" {
+			return
+		}
+		if errs := config.Validate(); len(errs) > 0 {
+			for _, err := range errs {
+				logger.Error(err)
+			}
+			logger.Fatalf("Invalid config file %s, see workspace config validate", viper.ConfigFileUsed())
+		}
+	},
 }
 
 func Execute() {
@@ -39,25 +76,26 @@ func Execute() {
 }
 
 func init() {
-	cobra.OnInitialize(initConfig)
 	rootCmd.PersistentFlags().BoolVarP(&debug, "debug", "d", false, "verbose logging")
-	rootCmd.PersistentFlags().StringVar(&cfgFile, "config", "", "config file (default is $HOME/.ocm-workspace.yaml)")
+	rootCmd.PersistentFlags().StringVar(&cfgFile, "config", "", "config file (default is $OCM_WORKSPACE_CONFIG or $HOME/.ocm-workspace.yaml)")
+	rootCmd.PersistentFlags().StringVar(&profile, "profile", "", "config profile to use (default is $OCM_WORKSPACE_PROFILE)")
+	rootCmd.PersistentFlags().String("engine", "", "container engine to use: podman, docker or nerdctl (default is podman)")
+	viper.BindPFlag("containerEngine", rootCmd.PersistentFlags().Lookup("engine"))
 	rootCmd.Flags().BoolP("toggle", "t", false, "Help message for toggle")
 }
 
 func initConfig() {
+	if cfgFile == "" {
+		// Set to the effective config mounted in the workspace container
+		cfgFile = os.Getenv("OCM_WORKSPACE_CONFIG")
+	}
+
 	if cfgFile != "" {
 		viper.SetConfigFile(cfgFile)
 	} else {
-		var src string
-		if os.Getenv("IS_IN_CONTAINER") == "true" {
-			src = "/"
-		} else {
-			home, err := os.UserHomeDir()
-			src = home
-			cobra.CheckErr(err)
-		}
-		viper.AddConfigPath(src)
+		home, err := os.UserHomeDir()
+		cobra.CheckErr(err)
+		viper.AddConfigPath(home)
 		viper.SetConfigType("yaml")
 		viper.SetConfigName(".ocm-workspace")
 	}
@@ -70,5 +108,27 @@ func initConfig() {
 		glog.Fatal("Failed to read config file: ", err)
 	}
 
+	// The includes, drop-in files and profiles are already merged into the
+	// config of the workspace container
+	configSources, err = pkgInt.LoadConfigSources(viper.ConfigFileUsed(), !isInContainer())
+	if err != nil {
+		logger.Fatal("Failed to load config files: ", err)
+	}
+
+	if len(profile) == 0 {
+		profile = os.Getenv("OCM_WORKSPACE_PROFILE")
+	}
+	if len(profile) > 0 && !isInContainer() {
+		err = configSources.ApplyProfile(profile)
+		if err != nil {
+			logger.Fatal("Failed to apply the config profile: ", err)
+		}
+	}
+
+	err = viper.MergeConfigMap(configSources.GetSettings())
+	if err != nil {
+		logger.Fatal("Failed to merge config files: ", err)
+	}
+
 	config = pkgInt.NewOcmWorkspaceConfig()
 }
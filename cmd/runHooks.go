@@ -0,0 +1,55 @@
+/*
+Copyright © 2023 Jose Cueto
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+package cmd
+
+import (
+	logger "github.com/sirupsen/logrus"
+	"github.com/spf13/cobra"
+
+	pkgInt "ocm-workspace/internal"
+)
+
+var runHooksCmd = &cobra.Command{
+	Use:    "runHooks <event>",
+	Short:  "Runs the plugins of a hook event in the workspace container.",
+	Hidden: true,
+	Args:   cobra.ExactArgs(1),
+	PreRun: toggleDebug,
+	Run: func(cmd *cobra.Command, args []string) {
+		if err := checkContainerCommand(); err != nil {
+			logger.Fatal(err)
+		}
+
+		event := args[0]
+		var envVars [][]string
+		if event == pkgInt.HookNamespaceChange {
+			// Set by the shell hook, which tracks the namespace
+			envVars = [][]string{
+				{"NAMESPACE", getEnvVar("NAMESPACE")},
+				{"PREVIOUS_NAMESPACE", getEnvVar("PREVIOUS_NAMESPACE")},
+			}
+		}
+
+		err := runHooks(event, envVars)
+		if err != nil {
+			logger.Fatal(err)
+		}
+	},
+}
+
+func init() {
+	rootCmd.AddCommand(runHooksCmd)
+}
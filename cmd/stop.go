@@ -0,0 +1,119 @@
+/*
+Copyright © 2023 Jose Cueto
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+package cmd
+
+import (
+	"errors"
+	"fmt"
+	"os/exec"
+	"path/filepath"
+
+	logger "github.com/sirupsen/logrus"
+	"github.com/spf13/cobra"
+
+	pkgInt "ocm-workspace/internal"
+	pkgIntHelper "ocm-workspace/internal/helpers"
+)
+
+var (
+	stopCmdArgs struct {
+		all bool
+	}
+)
+
+var stopCmd = &cobra.Command{
+	Use:         "stop [cluster or container]...",
+	Short:       "Stops workspace containers.",
+	Long:        `Stops workspace containers including their OpenShift console containers and background plugins.`,
+	Annotations: map[string]string{skipValidationAnnotation: "true"},
+	PreRun:      toggleDebug,
+	Run:         onStop,
+}
+
+func onStop(cmd *cobra.Command, args []string) {
+	ce := newContainerEngine()
+	for _, ws := range resolveWorkspaces(ce, args, stopCmdArgs.all, false) {
+		err := stopWorkspace(ce, &ws)
+		if err != nil {
+			logger.Fatalf("Failed to stop workspace %s: %v", ws.Name, err)
+		}
+		logger.Infof("Stopped workspace %s", ws.Name)
+	}
+}
+
+// Stops the console container and the plugins of a running workspace before
+// stopping the workspace container. The console's bearer token refresher is
+// stopped even if the workspace is not running.
+func stopWorkspace(ce pkgInt.ContainerEngine, ws *pkgInt.Workspace) error {
+	stopConsoleRefresher(ws.Name)
+	if !ws.Running {
+		return nil
+	}
+
+	consoleContainerName := pkgInt.GetConsoleContainerName(ws.Name)
+	_, err := pkgIntHelper.RunCommandOutput(ce.GetExecName(), ce.GetStopArgs(consoleContainerName)...)
+	if err != nil {
+		logger.Debugf("No console container %s stopped: %v", consoleContainerName, err)
+	}
+
+	clusterConfig := config.ForCluster(ws.Cluster)
+	if len(clusterConfig.GetHookPlugins(pkgInt.HookContainerStop)) > 0 {
+		out, err := pkgIntHelper.RunCommandOutput(
+			ce.GetExecName(),
+			ce.GetExecArgs(ws.Name, "", false, "/usr/bin/workspace", "runHooks", pkgInt.HookContainerStop)...,
+		)
+		if err != nil {
+			logger.Warnf("The %s hooks of workspace %s failed: %v\n%s", pkgInt.HookContainerStop, ws.Name, err, out)
+		}
+	}
+
+	// Terminate the background plugins and remove their generated config
+	// files. pkill runs without a shell, whose command line would match the
+	// pattern as well.
+	configFiles := []string{"rm", "-f"}
+	for _, plug := range clusterConfig.Plugins {
+		pattern := fmt.Sprintf("/usr/bin/%s", filepath.Base(plug.ExecPath))
+		_, err = pkgIntHelper.RunCommandOutput(ce.GetExecName(), ce.GetExecArgs(ws.Name, "", false, "pkill", "-TERM", "-f", pattern)...)
+
+		// pkill exits with 1 if no process matched
+		var exitErr *exec.ExitError
+		if err != nil && !(errors.As(err, &exitErr) && exitErr.ExitCode() == 1) {
+			logger.Warnf("Failed to terminate plugin %s of workspace %s: %v", plug.Name, ws.Name, err)
+		}
+		configFiles = append(configFiles, fmt.Sprintf("%s/.%s.yaml", config.UserHome, plug.Name))
+	}
+	if len(clusterConfig.Plugins) > 0 {
+		_, err = pkgIntHelper.RunCommandOutput(ce.GetExecName(), ce.GetExecArgs(ws.Name, "", false, configFiles...)...)
+		if err != nil {
+			logger.Warnf("Failed to remove the plugin config files of workspace %s: %v", ws.Name, err)
+		}
+	}
+
+	_, err = pkgIntHelper.RunCommandOutput(ce.GetExecName(), ce.GetStopArgs(ws.Name)...)
+	return err
+}
+
+func init() {
+	rootCmd.AddCommand(stopCmd)
+
+	stopCmd.Flags().BoolVarP(
+		&stopCmdArgs.all,
+		"all",
+		"a",
+		false,
+		"Stop all running workspaces.",
+	)
+}
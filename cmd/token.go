@@ -0,0 +1,77 @@
+/*
+Copyright © 2023 Jose Cueto
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+package cmd
+
+import (
+	logger "github.com/sirupsen/logrus"
+	"github.com/spf13/cobra"
+
+	pkgIntToken "ocm-workspace/internal/token"
+)
+
+var (
+	tokenCmdArgs struct {
+		ocmEnvironment string
+		backend        string
+	}
+)
+
+var tokenCmd = &cobra.Command{
+	Use:   "token",
+	Short: "Manages the OCM tokens stored in the host keyring.",
+	Long: `Manages the OCM offline tokens stored in the host keyring (Secret Service or pass) per OCM environment.
+Use a token source of type keyring in the config file to log in with a stored token.`,
+}
+
+// Creates the keyring store of the --backend flag, defaults to the backend of
+// the OCM environment's keyring token source.
+func newTokenStore() pkgIntToken.Store {
+	ocmEnvironment, err := config.ResolveOcmEnvironment(tokenCmdArgs.ocmEnvironment)
+	if err != nil {
+		logger.Fatal(err)
+	}
+
+	backend := tokenCmdArgs.backend
+	if len(backend) == 0 && ocmEnvironment.TokenSource.Type == pkgIntToken.SourceKeyring {
+		backend = ocmEnvironment.TokenSource.Backend
+	}
+
+	store, err := pkgIntToken.NewStore(backend)
+	if err != nil {
+		logger.Fatal(err)
+	}
+	return store
+}
+
+func init() {
+	rootCmd.AddCommand(tokenCmd)
+
+	flags := tokenCmd.PersistentFlags()
+	flags.StringVarP(
+		&tokenCmdArgs.ocmEnvironment,
+		"env",
+		"e",
+		"production",
+		"OCM environment of the token.",
+	)
+
+	flags.StringVar(
+		&tokenCmdArgs.backend,
+		"backend",
+		"",
+		"Keyring backend: secret-service or pass (default is the token source's backend or secret-service).",
+	)
+}
@@ -0,0 +1,42 @@
+/*
+Copyright © 2023 Jose Cueto
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+package cmd
+
+import (
+	"fmt"
+
+	logger "github.com/sirupsen/logrus"
+	"github.com/spf13/cobra"
+)
+
+var tokenGetCmd = &cobra.Command{
+	Use:    "get",
+	Short:  "Prints the OCM token stored in the host keyring.",
+	Args:   cobra.NoArgs,
+	PreRun: toggleDebug,
+	Run: func(cmd *cobra.Command, args []string) {
+		store := newTokenStore()
+		ocmToken, err := store.Get(tokenCmdArgs.ocmEnvironment)
+		if err != nil {
+			logger.Fatal(err)
+		}
+		fmt.Println(ocmToken)
+	},
+}
+
+func init() {
+	tokenCmd.AddCommand(tokenGetCmd)
+}
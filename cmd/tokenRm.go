@@ -0,0 +1,41 @@
+/*
+Copyright © 2023 Jose Cueto
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+package cmd
+
+import (
+	logger "github.com/sirupsen/logrus"
+	"github.com/spf13/cobra"
+)
+
+var tokenRmCmd = &cobra.Command{
+	Use:         "rm",
+	Short:       "Removes the OCM token stored in the host keyring.",
+	Args:        cobra.NoArgs,
+	Annotations: map[string]string{skipValidationAnnotation: "true"},
+	PreRun:      toggleDebug,
+	Run: func(cmd *cobra.Command, args []string) {
+		store := newTokenStore()
+		err := store.Remove(tokenCmdArgs.ocmEnvironment)
+		if err != nil {
+			logger.Fatalf("Failed to remove the token from the %s: %v", store, err)
+		}
+		logger.Infof("Removed the token of OCM environment %s from the %s", tokenCmdArgs.ocmEnvironment, store)
+	},
+}
+
+func init() {
+	tokenCmd.AddCommand(tokenRmCmd)
+}
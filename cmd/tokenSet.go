@@ -0,0 +1,73 @@
+/*
+Copyright © 2023 Jose Cueto
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+package cmd
+
+import (
+	"fmt"
+	"io"
+	"os"
+
+	logger "github.com/sirupsen/logrus"
+	"github.com/spf13/cobra"
+
+	pkgIntToken "ocm-workspace/internal/token"
+)
+
+var tokenSetCmd = &cobra.Command{
+	Use:   "set",
+	Short: "Stores an OCM offline token in the host keyring.",
+	Long: `Stores an OCM offline (or refresh) token read from stdin in the host keyring, e.g.
+
+  ocm token --refresh | workspace token set --env production`,
+	Args:   cobra.NoArgs,
+	PreRun: toggleDebug,
+	Run:    onTokenSet,
+}
+
+func onTokenSet(cmd *cobra.Command, args []string) {
+	if info, err := os.Stdin.Stat(); err == nil && info.Mode()&os.ModeCharDevice != 0 {
+		fmt.Fprintln(os.Stderr, "Paste the OCM token, then press Enter and Ctrl+D:")
+	}
+
+	content, err := io.ReadAll(os.Stdin)
+	if err != nil {
+		logger.Fatal("Failed to read the token: ", err)
+	}
+
+	ocmEnvironment, err := config.ResolveOcmEnvironment(tokenCmdArgs.ocmEnvironment)
+	if err != nil {
+		logger.Fatal(err)
+	}
+
+	// Access tokens expire within minutes and are not worth storing
+	validateOpts := ocmEnvironment.GetTokenValidateOptions()
+	validateOpts.Kinds = []string{pkgIntToken.KindOffline, pkgIntToken.KindRefresh}
+	ocmToken, err := pkgIntToken.ParseAndValidate(string(content), validateOpts)
+	if err != nil {
+		logger.Fatal("Invalid OCM token: ", err)
+	}
+
+	store := newTokenStore()
+	err = store.Set(tokenCmdArgs.ocmEnvironment, ocmToken.Raw)
+	if err != nil {
+		logger.Fatalf("Failed to store the token in the %s: %v", store, err)
+	}
+	logger.Infof("Stored the %s token of OCM environment %s in the %s", ocmToken.Kind(), tokenCmdArgs.ocmEnvironment, store)
+}
+
+func init() {
+	tokenCmd.AddCommand(tokenSetCmd)
+}
@@ -17,10 +17,48 @@ limitations under the License.
 package internal
 
 import (
-	"errors"
 	"fmt"
+	"path/filepath"
+	"strings"
 )
 
+// ContainerEngine builds the command line arguments of a container engine CLI
+// (e.g. podman, docker). Each implementation translates the environment
+// variables, volume maps, port maps and build args into its own CLI dialect.
+type ContainerEngine interface {
+	AppendEnvVar(key string, value string)
+	GetEnvVars() [][]string
+	AppendVolMap(hostVol string, containerVol string, mapAttrs string)
+	GetVolMaps() [][]string
+	AppendPortMap(hostPort string, containerPort string, hostAddr string)
+	GetPortMaps() [][]string
+	AppendBuildArg(name string, value string)
+	AppendLabel(key string, value string)
+	GetLabels() [][]string
+	ToEnvVarArgs() []string
+	ToVolMapArgs() []string
+	ToPortMapArgs() []string
+	ToBuildArgs() []string
+	ToLabelArgs() []string
+	GetRunArgs(containerName string, entryPoint string, image string, entryPointArgs ...string) []string
+	GetSidecarRunArgs(containerName string, networkContainer string, image string, detach bool, cmdArgs ...string) []string
+	GetExecArgs(containerName string, user string, interactive bool, cmdArgs ...string) []string
+	GetPullArgs(image string) []string
+	GetImageInspectArgs(image string) []string
+	GetImageRmArgs(images ...string) []string
+	GetCreateArgs(containerName string, image string, cmdArgs ...string) []string
+	GetCpArgs(src string, dst string) []string
+	GetPsArgs(all bool, labelFilters ...string) []string
+	GetInspectArgs(containers ...string) []string
+	GetStopArgs(containers ...string) []string
+	GetRmArgs(force bool, containers ...string) []string
+	GetRegistryAuthEnvVars(authFile string) [][]string
+	GetBuildArgs() []string
+	GetExecName() string
+}
+
+var supportedContainerEngines = []string{"podman", "docker", "nerdctl"}
+
 type ceFactory struct {
 	args map[string]interface{}
 }
@@ -31,35 +69,43 @@ func NewCeFactory(args map[string]interface{}) *ceFactory {
 	}
 }
 
-func (cf *ceFactory) Create() (*podman, error) {
-	if cf.args["ceName"] == "podman" {
+func (cf *ceFactory) Create() (ContainerEngine, error) {
+	switch cf.args["ceName"] {
+	case "podman":
 		return NewPodman(), nil
-	} else {
-		return nil, errors.New("container engine name is not supported")
+	case "docker":
+		return NewDocker(), nil
+	case "nerdctl":
+		return NewNerdctl(), nil
+	default:
+		return nil, fmt.Errorf(
+			"container engine name %v is not supported (supported: %s)",
+			cf.args["ceName"],
+			strings.Join(supportedContainerEngines, ", "),
+		)
 	}
 }
 
-type podman struct {
+// ceArgs holds the engine independent values used to build container engine
+// arguments.
+type ceArgs struct {
 	envVars      [][]string
 	volMaps      [][]string
 	volMapsAttr  map[string]string
 	portMaps     [][]string
 	portMapAddrs map[string]string
 	buildArgs    [][]string
+	labels       [][]string
 }
 
-func NewPodman() *podman {
-	return &podman{}
-}
-
-func (p *podman) AppendEnvVar(key string, value string) {
+func (c *ceArgs) AppendEnvVar(key string, value string) {
 	env := []string{key, value}
-	p.envVars = append(p.envVars, env)
+	c.envVars = append(c.envVars, env)
 }
 
-func (p *podman) ToEnvVarArgs() []string {
+func (c *ceArgs) ToEnvVarArgs() []string {
 	args := []string{}
-	for _, val := range p.envVars {
+	for _, val := range c.envVars {
 		k := val[0]
 		v := val[1]
 		envVarDef := fmt.Sprintf("%s=%s", k, v)
@@ -68,61 +114,65 @@ func (p *podman) ToEnvVarArgs() []string {
 	return args
 }
 
-func (p *podman) AppendVolMap(hostVol string, containerVol string, mapAttrs string) {
+func (c *ceArgs) AppendVolMap(hostVol string, containerVol string, mapAttrs string) {
 	vol := []string{hostVol, containerVol}
-	p.volMaps = append(p.volMaps, vol)
+	c.volMaps = append(c.volMaps, vol)
 
-	if p.volMapsAttr == nil {
-		p.volMapsAttr = map[string]string{hostVol: mapAttrs}
+	if c.volMapsAttr == nil {
+		c.volMapsAttr = map[string]string{hostVol: mapAttrs}
 	} else {
-		p.volMapsAttr[hostVol] = mapAttrs
+		c.volMapsAttr[hostVol] = mapAttrs
 	}
-
 }
 
-func (p *podman) ToVolMapArgs() []string {
+// Builds the volume map args keeping only the map attributes that the
+// engine supports. A nil supportedAttrs keeps all attributes.
+func (c *ceArgs) toVolMapArgs(supportedAttrs map[string]bool) []string {
 	args := []string{}
-	for _, val := range p.volMaps {
+	for _, val := range c.volMaps {
 		hostVol := val[0]
 		contVol := val[1]
-		mapAttrs := p.volMapsAttr[hostVol]
-		volMap := fmt.Sprintf("%s:%s:%s", hostVol, contVol, mapAttrs)
+		mapAttrs := filterVolMapAttrs(c.volMapsAttr[hostVol], supportedAttrs)
+		volMap := fmt.Sprintf("%s:%s", hostVol, contVol)
+		if len(mapAttrs) > 0 {
+			volMap = fmt.Sprintf("%s:%s", volMap, mapAttrs)
+		}
 		args = append(args, "-v", volMap)
 	}
 	return args
 }
 
-func (p *podman) AppendPortMap(hostPort string, containerPort string, hostAddr string) {
+func (c *ceArgs) AppendPortMap(hostPort string, containerPort string, hostAddr string) {
 	port := []string{hostPort, containerPort}
-	p.portMaps = append(p.portMaps, port)
+	c.portMaps = append(c.portMaps, port)
 
-	if p.portMapAddrs == nil {
-		p.portMapAddrs = map[string]string{hostPort: hostAddr}
+	if c.portMapAddrs == nil {
+		c.portMapAddrs = map[string]string{hostPort: hostAddr}
 	} else {
-		p.portMapAddrs[hostPort] = hostAddr
+		c.portMapAddrs[hostPort] = hostAddr
 	}
 }
 
-func (p *podman) ToPortMapArgs() []string {
+func (c *ceArgs) ToPortMapArgs() []string {
 	args := []string{}
-	for _, val := range p.portMaps {
+	for _, val := range c.portMaps {
 		hostPort := val[0]
 		containerPort := val[1]
-		hostAddr := p.portMapAddrs[hostPort]
+		hostAddr := c.portMapAddrs[hostPort]
 		portMap := fmt.Sprintf("%s:%s:%s", hostAddr, hostPort, containerPort)
 		args = append(args, "-p", portMap)
 	}
 	return args
 }
 
-func (p *podman) AppendBuildArg(name string, value string) {
+func (c *ceArgs) AppendBuildArg(name string, value string) {
 	buildArg := []string{name, value}
-	p.buildArgs = append(p.buildArgs, buildArg)
+	c.buildArgs = append(c.buildArgs, buildArg)
 }
 
-func (p *podman) ToBuildArgs() []string {
+func (c *ceArgs) ToBuildArgs() []string {
 	args := []string{}
-	for _, val := range p.buildArgs {
+	for _, val := range c.buildArgs {
 		name := val[0]
 		value := val[1]
 		buildArg := fmt.Sprintf("%s=%s", name, value)
@@ -131,8 +181,38 @@ func (p *podman) ToBuildArgs() []string {
 	return args
 }
 
-func (p *podman) GetRunArgs(containerName string, entryPoint string, image string, entryPointArgs ...string) []string {
+func (c *ceArgs) AppendLabel(key string, value string) {
+	label := []string{key, value}
+	c.labels = append(c.labels, label)
+}
 
+func (c *ceArgs) ToLabelArgs() []string {
+	args := []string{}
+	for _, val := range c.labels {
+		label := fmt.Sprintf("%s=%s", val[0], val[1])
+		args = append(args, "--label", label)
+	}
+	return args
+}
+
+func (c *ceArgs) GetLabels() [][]string {
+	return c.labels
+}
+
+func (c *ceArgs) GetEnvVars() [][]string {
+	return c.envVars
+}
+
+func (c *ceArgs) GetVolMaps() [][]string {
+	return c.volMaps
+}
+
+func (c *ceArgs) GetPortMaps() [][]string {
+	return c.portMaps
+}
+
+// Builds the run args shared by the docker compatible CLIs.
+func (c *ceArgs) getRunArgs(volMapArgs []string, containerName string, entryPoint string, image string, entryPointArgs ...string) []string {
 	runCmd := []string{
 		"run",
 		"--name",
@@ -140,9 +220,10 @@ func (p *podman) GetRunArgs(containerName string, entryPoint string, image strin
 		"-it",
 		"--privileged",
 	}
-	runCmd = append(runCmd, p.ToEnvVarArgs()...)
-	runCmd = append(runCmd, p.ToPortMapArgs()...)
-	runCmd = append(runCmd, p.ToVolMapArgs()...)
+	runCmd = append(runCmd, c.ToLabelArgs()...)
+	runCmd = append(runCmd, c.ToEnvVarArgs()...)
+	runCmd = append(runCmd, c.ToPortMapArgs()...)
+	runCmd = append(runCmd, volMapArgs...)
 	ep := []string{
 		"--entrypoint",
 		entryPoint,
@@ -153,30 +234,128 @@ func (p *podman) GetRunArgs(containerName string, entryPoint string, image strin
 	return runCmd
 }
 
-func (p *podman) GetExecName() string {
-	return "podman"
+// Builds the run args of a container that shares the network namespace of
+// another container. A detached container runs in the background.
+func (c *ceArgs) GetSidecarRunArgs(containerName string, networkContainer string, image string, detach bool, cmdArgs ...string) []string {
+	runCmd := []string{
+		"run",
+		"--rm",
+		"--network",
+		fmt.Sprintf("container:%s", networkContainer),
+		"--name",
+		containerName,
+	}
+	if detach {
+		runCmd = append(runCmd, "-d")
+	}
+	runCmd = append(runCmd, c.ToLabelArgs()...)
+	runCmd = append(runCmd, c.ToEnvVarArgs()...)
+	runCmd = append(runCmd, image)
+	runCmd = append(runCmd, cmdArgs...)
+	return runCmd
+}
+
+func (c *ceArgs) GetExecArgs(containerName string, user string, interactive bool, cmdArgs ...string) []string {
+	execCmd := []string{"exec"}
+	if interactive {
+		execCmd = append(execCmd, "-it")
+	}
+	if len(user) > 0 {
+		execCmd = append(execCmd, "--user", user)
+	}
+	execCmd = append(execCmd, containerName)
+	execCmd = append(execCmd, cmdArgs...)
+	return execCmd
+}
+
+func (c *ceArgs) GetPullArgs(image string) []string {
+	return []string{"pull", "--quiet", image}
+}
+
+func (c *ceArgs) GetImageInspectArgs(image string) []string {
+	return []string{"image", "inspect", image}
+}
+
+func (c *ceArgs) GetImageRmArgs(images ...string) []string {
+	rmCmd := []string{"image", "rm"}
+	rmCmd = append(rmCmd, images...)
+	return rmCmd
+}
+
+// Builds the args that create a container of an image without starting it.
+func (c *ceArgs) GetCreateArgs(containerName string, image string, cmdArgs ...string) []string {
+	createCmd := []string{"create", "--name", containerName, image}
+	createCmd = append(createCmd, cmdArgs...)
+	return createCmd
 }
 
-func (p *podman) GetEnvVars() [][]string {
-	return p.envVars
+// Builds the args that copy files between a container and the host, the
+// container paths are prefixed with "<container>:".
+func (c *ceArgs) GetCpArgs(src string, dst string) []string {
+	return []string{"cp", src, dst}
 }
 
-func (p *podman) GetVolMaps() [][]string {
-	return p.volMaps
+// Builds the args that list the IDs of the containers matching all of the
+// label filters (e.g. "key" or "key=value").
+func (c *ceArgs) GetPsArgs(all bool, labelFilters ...string) []string {
+	psCmd := []string{"ps", "-q", "--no-trunc"}
+	if all {
+		psCmd = append(psCmd, "-a")
+	}
+	for _, filter := range labelFilters {
+		psCmd = append(psCmd, "--filter", fmt.Sprintf("label=%s", filter))
+	}
+	return psCmd
 }
 
-func (p *podman) GetPortMaps() [][]string {
-	return p.portMaps
+func (c *ceArgs) GetInspectArgs(containers ...string) []string {
+	inspectCmd := []string{"container", "inspect"}
+	inspectCmd = append(inspectCmd, containers...)
+	return inspectCmd
 }
 
-func (p *podman) GetBuildArgs() []string {
+func (c *ceArgs) GetStopArgs(containers ...string) []string {
+	stopCmd := []string{"stop"}
+	stopCmd = append(stopCmd, containers...)
+	return stopCmd
+}
 
+func (c *ceArgs) GetRmArgs(force bool, containers ...string) []string {
+	rmCmd := []string{"rm"}
+	if force {
+		rmCmd = append(rmCmd, "-f")
+	}
+	rmCmd = append(rmCmd, containers...)
+	return rmCmd
+}
+
+func (c *ceArgs) GetBuildArgs() []string {
 	buildCmd := []string{
 		"build",
 		"-t",
 		"ocm-workspace",
 	}
-	buildCmd = append(buildCmd, p.ToBuildArgs()...)
+	buildCmd = append(buildCmd, c.ToBuildArgs()...)
 	buildCmd = append(buildCmd, ".")
 	return buildCmd
 }
+
+// Docker compatible CLIs read registry credentials from $DOCKER_CONFIG/config.json.
+func dockerRegistryAuthEnvVars(authFile string) [][]string {
+	return [][]string{{"DOCKER_CONFIG", filepath.Dir(authFile)}}
+}
+
+func filterVolMapAttrs(mapAttrs string, supportedAttrs map[string]bool) string {
+	if supportedAttrs == nil {
+		return mapAttrs
+	}
+
+	attrs := []string{}
+	for _, attr := range strings.Split(mapAttrs, ",") {
+		attr = strings.TrimSpace(attr)
+		if supportedAttrs[attr] {
+			attrs = append(attrs, attr)
+		}
+	}
+	return strings.Join(attrs, ",")
+}
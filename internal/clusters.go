@@ -0,0 +1,88 @@
+/*
+Copyright © 2023 Jose Cueto
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package internal
+
+import (
+	"path/filepath"
+	"sort"
+	"strings"
+)
+
+// ClusterConfig overlays the config when logging into a matching cluster.
+type ClusterConfig struct {
+	CustomDirMaps    []DirMap `mapstructure:"customDirMaps" yaml:"customDirMaps,omitempty"`
+	Plugins          []Plugin `mapstructure:"plugins" yaml:"plugins,omitempty"`
+	ExportEnvVars    []string `mapstructure:"exportEnvVars" yaml:"exportEnvVars,omitempty"`
+	AddToPATHEnv     []string `mapstructure:"addToPATHEnv" yaml:"addToPATHEnv,omitempty"`
+	DefaultNamespace string   `mapstructure:"defaultNamespace" yaml:"defaultNamespace,omitempty"`
+}
+
+// Gets the keys of the clusters section matching a cluster name or ID. Glob
+// keys come first, so that the exact keys are applied last.
+func (c *OcmWorkspaceConfig) GetMatchingClusterKeys(cluster string) []string {
+	cluster = strings.ToLower(cluster)
+	globs := []string{}
+	exact := []string{}
+	for key := range c.Clusters {
+		pattern := strings.ToLower(key)
+		if pattern == cluster {
+			exact = append(exact, key)
+			continue
+		}
+		if matched, err := filepath.Match(pattern, cluster); err == nil && matched {
+			globs = append(globs, key)
+		}
+	}
+	sort.Strings(globs)
+	sort.Strings(exact)
+	return append(globs, exact...)
+}
+
+// Gets the config of a cluster with the matching clusters entries overlaid.
+// Dir maps, env vars and PATH dirs are appended, plugins replace the plugins
+// of the same name and the default namespace is replaced.
+func (c *OcmWorkspaceConfig) ForCluster(cluster string) *OcmWorkspaceConfig {
+	conf := *c
+	conf.CustomDirMaps = append([]DirMap{}, c.CustomDirMaps...)
+	conf.Plugins = append([]Plugin{}, c.Plugins...)
+	conf.ExportEnvVars = append([]string{}, c.ExportEnvVars...)
+	conf.AddToPATHEnv = append([]string{}, c.AddToPATHEnv...)
+
+	for _, key := range c.GetMatchingClusterKeys(cluster) {
+		overlay := c.Clusters[key]
+		conf.CustomDirMaps = append(conf.CustomDirMaps, overlay.CustomDirMaps...)
+		conf.ExportEnvVars = append(conf.ExportEnvVars, overlay.ExportEnvVars...)
+		conf.AddToPATHEnv = append(conf.AddToPATHEnv, overlay.AddToPATHEnv...)
+		if len(overlay.DefaultNamespace) > 0 {
+			conf.DefaultNamespace = overlay.DefaultNamespace
+		}
+
+		for _, plug := range overlay.Plugins {
+			replaced := false
+			for idx := range conf.Plugins {
+				if conf.Plugins[idx].Name == plug.Name {
+					conf.Plugins[idx] = plug
+					replaced = true
+				}
+			}
+			if !replaced {
+				conf.Plugins = append(conf.Plugins, plug)
+			}
+		}
+	}
+	return &conf
+}
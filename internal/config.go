@@ -18,45 +18,80 @@ package internal
 
 import (
 	"log"
+	"strings"
 
 	"github.com/spf13/viper"
+
+	pkgIntToken "ocm-workspace/internal/token"
 )
 
 type DirMap struct {
-	HostDir      string `mapstructure:"hostDir"`
-	ContainerDir string `mapstructure:"containerDir"`
-	FileAttrs    string `mapstructure:"fileAttrs"`
+	HostDir      string `mapstructure:"hostDir" yaml:"hostDir,omitempty"`
+	ContainerDir string `mapstructure:"containerDir" yaml:"containerDir,omitempty"`
+	FileAttrs    string `mapstructure:"fileAttrs" yaml:"fileAttrs,omitempty"`
 }
 
 type PortMap struct {
-	HostPort      string `mapstructure:"hostPort"`
-	ContainerPort string `mapstructure:"containerPort"`
+	HostPort      string `mapstructure:"hostPort" json:"hostPort" yaml:"hostPort"`
+	ContainerPort string `mapstructure:"containerPort" json:"containerPort" yaml:"containerPort"`
 }
 
 type Plugin struct {
-	Name          string `mapstructure:"name"`
-	ExecPath      string `mapstructure:"execPath"`
-	Config        string `mapstructure:"config"`
-	RunOn         string `mapstructure:"runOn"`
-	AllocatePorts int    `mapstructure:"allocatePorts"`
-	ExecCommand   string `mapstructure:"execCommand"`
+	Name          string `mapstructure:"name" yaml:"name,omitempty"`
+	ExecPath      string `mapstructure:"execPath" yaml:"execPath,omitempty"`
+	Config        string `mapstructure:"config" yaml:"config,omitempty"`
+	RunOn         string `mapstructure:"runOn" yaml:"runOn,omitempty"`
+	AllocatePorts int    `mapstructure:"allocatePorts" yaml:"allocatePorts,omitempty"`
+	ExecCommand   string `mapstructure:"execCommand" yaml:"execCommand,omitempty"`
+	// Restart policy of a plugin run in the background: never (default),
+	// onFailure or always.
+	RestartPolicy string `mapstructure:"restartPolicy" yaml:"restartPolicy,omitempty"`
+	// Host environment variables passed to the plugin.
+	Env []string `mapstructure:"env" yaml:"env,omitempty"`
+
+	// Manifest of the installed plugin the entry references by name
+	manifest *PluginManifest
+}
+
+// ProxyConfig configures the egress proxy environment variables.
+type ProxyConfig struct {
+	Disabled   bool   `mapstructure:"disabled" yaml:"disabled,omitempty"`
+	HttpProxy  string `mapstructure:"httpProxy" yaml:"httpProxy,omitempty"`
+	HttpsProxy string `mapstructure:"httpsProxy" yaml:"httpsProxy,omitempty"`
+	NoProxy    string `mapstructure:"noProxy" yaml:"noProxy,omitempty"`
+}
+
+// Proxy is the default egress proxy with per OCM environment overrides.
+type Proxy struct {
+	ProxyConfig  `mapstructure:",squash" yaml:",inline"`
+	Environments map[string]ProxyConfig `mapstructure:"environments" yaml:"environments,omitempty"`
 }
 
 type OcmWorkspaceConfig struct {
-	CustomDirMaps         []DirMap  `mapstructure:"customDirMaps"`
-	AddToPATHEnv          []string  `mapstructure:"addToPATHEnv"`
-	ExportEnvVars         []string  `mapstructure:"exportEnvVars"`
-	HostUser              string    `mapstructure:"hostUser"`
-	OcUser                string    `mapstructure:"ocUser"`
-	UserHome              string    `mapstructure:"userHome"`
-	BackplaneConfigProd   string    `mapstructure:"backplaneConfigProd"`
-	BackplaneConfigStage  string    `mapstructure:"backplaneConfigStage"`
-	BaseImage             string    `mapstructure:"baseImage"`
-	OCMCLIVersion         string    `mapstructure:"ocmCLIVersion"`
-	BackplaneCLIVersion   string    `mapstructure:"backplaneCLIVersion"`
-	Plugins               []Plugin  `mapstructure:"plugins"`
-	CustomPortMaps        []PortMap `mapstructure:"customPortMaps"`
-	OcmLongLivedTokenPath string    `mapstructure:"ocmLongLivedTokenPath"`
+	CustomDirMaps         []DirMap                            `mapstructure:"customDirMaps" yaml:"customDirMaps,omitempty"`
+	AddToPATHEnv          []string                            `mapstructure:"addToPATHEnv" yaml:"addToPATHEnv,omitempty"`
+	ExportEnvVars         []string                            `mapstructure:"exportEnvVars" yaml:"exportEnvVars,omitempty"`
+	HostUser              string                              `mapstructure:"hostUser" yaml:"hostUser,omitempty"`
+	OcUser                string                              `mapstructure:"ocUser" yaml:"ocUser,omitempty"`
+	UserHome              string                              `mapstructure:"userHome" yaml:"userHome,omitempty"`
+	BackplaneConfigProd   string                              `mapstructure:"backplaneConfigProd" yaml:"backplaneConfigProd,omitempty"`
+	BackplaneConfigStage  string                              `mapstructure:"backplaneConfigStage" yaml:"backplaneConfigStage,omitempty"`
+	BaseImage             string                              `mapstructure:"baseImage" yaml:"baseImage,omitempty"`
+	OCMCLIVersion         string                              `mapstructure:"ocmCLIVersion" yaml:"ocmCLIVersion,omitempty"`
+	BackplaneCLIVersion   string                              `mapstructure:"backplaneCLIVersion" yaml:"backplaneCLIVersion,omitempty"`
+	Plugins               []Plugin                            `mapstructure:"plugins" yaml:"plugins,omitempty"`
+	CustomPortMaps        []PortMap                           `mapstructure:"customPortMaps" yaml:"customPortMaps,omitempty"`
+	OcmLongLivedTokenPath string                              `mapstructure:"ocmLongLivedTokenPath" yaml:"ocmLongLivedTokenPath,omitempty"`
+	ContainerEngine       string                              `mapstructure:"containerEngine" yaml:"containerEngine,omitempty"`
+	Proxy                 Proxy                               `mapstructure:"proxy" yaml:"proxy,omitempty"`
+	TokenSources          map[string]pkgIntToken.SourceConfig `mapstructure:"tokenSources" yaml:"tokenSources,omitempty"`
+	OcmEnvironments       map[string]OcmEnvironment           `mapstructure:"ocmEnvironments" yaml:"ocmEnvironments,omitempty"`
+	DefaultNamespace      string                              `mapstructure:"defaultNamespace" yaml:"defaultNamespace,omitempty"`
+	Clusters              map[string]ClusterConfig            `mapstructure:"clusters" yaml:"clusters,omitempty"`
+	// Included config files, merged by LoadConfigSources.
+	Include []string `mapstructure:"include" yaml:"-"`
+	// Profiles are merged into the config when selected, see ApplyProfile.
+	Profiles map[string]map[string]interface{} `mapstructure:"profiles" yaml:"-"`
 }
 
 func NewOcmWorkspaceConfig() *OcmWorkspaceConfig {
@@ -119,3 +154,61 @@ func (c *OcmWorkspaceConfig) GetPlugins() []Plugin {
 func (c *OcmWorkspaceConfig) GetOcmLongLivedTokenPath() string {
 	return c.OcmLongLivedTokenPath
 }
+
+// Gets the container engine name, defaults to podman.
+func (c *OcmWorkspaceConfig) GetContainerEngine() string {
+	if len(c.ContainerEngine) == 0 {
+		return "podman"
+	}
+	return c.ContainerEngine
+}
+
+// Gets the proxy config of an OCM environment. The proxy of the
+// ocmEnvironments entry or a proxy.environments entry replaces the default
+// proxy config.
+func (c *OcmWorkspaceConfig) GetProxy(ocmEnvironment string) ProxyConfig {
+	if env, ok := c.OcmEnvironments[ocmEnvironment]; ok && env.Proxy != nil {
+		return *env.Proxy
+	}
+	if proxy, ok := c.Proxy.Environments[ocmEnvironment]; ok {
+		return proxy
+	}
+	return c.Proxy.ProxyConfig
+}
+
+// Gets the proxy environment variables (upper and lower case), none if the
+// proxy is disabled.
+func (p ProxyConfig) GetEnvVars() [][]string {
+	envVars := [][]string{}
+	if p.Disabled {
+		return envVars
+	}
+
+	for _, env := range [][]string{
+		{"HTTP_PROXY", p.HttpProxy},
+		{"HTTPS_PROXY", p.HttpsProxy},
+		{"NO_PROXY", p.NoProxy},
+	} {
+		if len(env[1]) == 0 {
+			continue
+		}
+		envVars = append(envVars, env, []string{strings.ToLower(env[0]), env[1]})
+	}
+	return envVars
+}
+
+// Gets the OCM token source of an OCM environment from its ocmEnvironments
+// or tokenSources entry. Defaults to the ocmLongLivedTokenPath file if set,
+// otherwise to "ocm token".
+func (c *OcmWorkspaceConfig) GetTokenSource(ocmEnvironment string) pkgIntToken.SourceConfig {
+	if env, ok := c.OcmEnvironments[ocmEnvironment]; ok && env.TokenSource != nil {
+		return *env.TokenSource
+	}
+	if source, ok := c.TokenSources[ocmEnvironment]; ok {
+		return source
+	}
+	if len(c.OcmLongLivedTokenPath) > 0 {
+		return pkgIntToken.SourceConfig{Type: pkgIntToken.SourceFile, Path: c.OcmLongLivedTokenPath}
+	}
+	return pkgIntToken.SourceConfig{Type: pkgIntToken.SourceOcm}
+}
@@ -0,0 +1,58 @@
+/*
+Copyright © 2023 Jose Cueto
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+package internal
+
+// Volume map attributes understood by docker's -v flag.
+var dockerVolMapAttrs = map[string]bool{
+	"ro":         true,
+	"rw":         true,
+	"z":          true,
+	"Z":          true,
+	"nocopy":     true,
+	"consistent": true,
+	"cached":     true,
+	"delegated":  true,
+	"shared":     true,
+	"slave":      true,
+	"private":    true,
+	"rshared":    true,
+	"rslave":     true,
+	"rprivate":   true,
+}
+
+type docker struct {
+	ceArgs
+}
+
+func NewDocker() *docker {
+	return &docker{}
+}
+
+func (d *docker) ToVolMapArgs() []string {
+	return d.toVolMapArgs(dockerVolMapAttrs)
+}
+
+func (d *docker) GetRunArgs(containerName string, entryPoint string, image string, entryPointArgs ...string) []string {
+	return d.getRunArgs(d.ToVolMapArgs(), containerName, entryPoint, image, entryPointArgs...)
+}
+
+func (d *docker) GetRegistryAuthEnvVars(authFile string) [][]string {
+	return dockerRegistryAuthEnvVars(authFile)
+}
+
+func (d *docker) GetExecName() string {
+	return "docker"
+}
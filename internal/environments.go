@@ -0,0 +1,166 @@
+/*
+Copyright © 2023 Jose Cueto
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package internal
+
+import (
+	"fmt"
+	"net/url"
+	"regexp"
+	"sort"
+	"strings"
+
+	pkgIntToken "ocm-workspace/internal/token"
+)
+
+// OcmEnvironment configures an OCM environment. Unset fields fall back to
+// the built-in environment of the same name and the legacy config keys.
+type OcmEnvironment struct {
+	// OCM API URL passed to ocm login.
+	Url string `mapstructure:"url" yaml:"url,omitempty"`
+	// Expected issuer (iss claim) of the OCM tokens, any https issuer is
+	// accepted if empty.
+	Issuer string `mapstructure:"issuer" yaml:"issuer,omitempty"`
+	// Filename of the backplane config in ~/.config/backplane.
+	BackplaneConfig string                    `mapstructure:"backplaneConfig" yaml:"backplaneConfig,omitempty"`
+	TokenSource     *pkgIntToken.SourceConfig `mapstructure:"tokenSource" yaml:"tokenSource,omitempty"`
+	// Egress proxy of the workspace and its OpenShift console.
+	Proxy *ProxyConfig `mapstructure:"proxy" yaml:"proxy,omitempty"`
+}
+
+// ResolvedOcmEnvironment is an OCM environment with all of its fallbacks
+// applied.
+type ResolvedOcmEnvironment struct {
+	Name            string
+	Url             string
+	Issuer          string
+	BackplaneConfig string
+	TokenSource     pkgIntToken.SourceConfig
+	Proxy           ProxyConfig
+}
+
+// Red Hat SSO realms issuing the OCM tokens.
+const (
+	ssoIssuerProduction = "https://sso.redhat.com/auth/realms/redhat-external"
+	ssoIssuerStage      = "https://sso.stage.redhat.com/auth/realms/redhat-external"
+)
+
+var builtinOcmEnvironments = map[string]OcmEnvironment{
+	"production":  {Url: "https://api.openshift.com", Issuer: ssoIssuerProduction},
+	"staging":     {Url: "https://api.stage.openshift.com", Issuer: ssoIssuerStage},
+	"integration": {Url: "https://api.integration.openshift.com", Issuer: ssoIssuerStage},
+}
+
+// OCM environment names are used in container labels and keyring entries.
+var ocmEnvironmentNameRegexp = regexp.MustCompile(`^[a-z0-9]([-a-z0-9]*[a-z0-9])?$`)
+
+// Gets the configured OCM environment merged over the built-in one.
+func (c *OcmWorkspaceConfig) GetOcmEnvironment(name string) (OcmEnvironment, bool) {
+	builtin, isBuiltin := builtinOcmEnvironments[name]
+	env, isConfigured := c.OcmEnvironments[name]
+	if !isConfigured {
+		return builtin, isBuiltin
+	}
+
+	if len(env.Url) == 0 {
+		env.Url = builtin.Url
+	}
+	if len(env.Issuer) == 0 {
+		env.Issuer = builtin.Issuer
+	}
+	return env, true
+}
+
+// Gets the names of the built-in and configured OCM environments.
+func (c *OcmWorkspaceConfig) GetOcmEnvironmentNames() []string {
+	names := []string{}
+	for name := range builtinOcmEnvironments {
+		names = append(names, name)
+	}
+	for name := range c.OcmEnvironments {
+		if _, ok := builtinOcmEnvironments[name]; !ok {
+			names = append(names, name)
+		}
+	}
+	sort.Strings(names)
+	return names
+}
+
+// Resolves an OCM environment by name. Unknown or invalid names and
+// environments without a valid https URL are rejected.
+func (c *OcmWorkspaceConfig) ResolveOcmEnvironment(name string) (*ResolvedOcmEnvironment, error) {
+	if !ocmEnvironmentNameRegexp.MatchString(name) {
+		return nil, fmt.Errorf(
+			"invalid OCM environment name %q, use lower case alphanumeric characters or '-'",
+			name,
+		)
+	}
+
+	env, ok := c.GetOcmEnvironment(name)
+	if !ok {
+		return nil, fmt.Errorf(
+			"unknown OCM environment %s (known: %s), add it to ocmEnvironments in the config file",
+			name,
+			strings.Join(c.GetOcmEnvironmentNames(), ", "),
+		)
+	}
+
+	apiUrl, err := url.Parse(env.Url)
+	if err != nil || apiUrl.Scheme != "https" || len(apiUrl.Host) == 0 {
+		return nil, fmt.Errorf("OCM environment %s has an invalid url %q, an https URL is required", name, env.Url)
+	}
+	if len(env.Issuer) > 0 {
+		issuer, err := url.Parse(env.Issuer)
+		if err != nil || issuer.Scheme != "https" || len(issuer.Host) == 0 {
+			return nil, fmt.Errorf("OCM environment %s has an invalid issuer %q, an https URL is required", name, env.Issuer)
+		}
+	}
+
+	return &ResolvedOcmEnvironment{
+		Name:            name,
+		Url:             env.Url,
+		Issuer:          env.Issuer,
+		BackplaneConfig: c.GetBackplaneConfig(name),
+		TokenSource:     c.GetTokenSource(name),
+		Proxy:           c.GetProxy(name),
+	}, nil
+}
+
+// Gets the validation options of the OCM tokens of the environment.
+func (e *ResolvedOcmEnvironment) GetTokenValidateOptions() pkgIntToken.ValidateOptions {
+	opts := pkgIntToken.ValidateOptions{}
+	if len(e.Issuer) > 0 {
+		opts.Issuers = []string{e.Issuer}
+	}
+	return opts
+}
+
+// Gets the backplane config filename of an OCM environment. Defaults to
+// backplaneConfigProd for production and backplaneConfigStage for staging.
+func (c *OcmWorkspaceConfig) GetBackplaneConfig(ocmEnvironment string) string {
+	if env, ok := c.OcmEnvironments[ocmEnvironment]; ok && len(env.BackplaneConfig) > 0 {
+		return env.BackplaneConfig
+	}
+
+	switch ocmEnvironment {
+	case "production":
+		return c.BackplaneConfigProd
+	case "staging":
+		return c.BackplaneConfigStage
+	default:
+		return ""
+	}
+}
@@ -97,15 +97,12 @@ func OcGetConfig(runAsOcUser string) (*OcConfig, error) {
 	return &config, nil
 }
 
+// Gets the OCM token of the host's OCM session. The token is validated by
+// the caller.
 func OcmGetOCMToken() (string, error) {
 	out, err := exec.Command("ocm", "token").Output()
-	var ocmToken string
-
-	if err == nil {
-		ocmToken = string(out[:])
-		if len(ocmToken) < 2000 {
-			err = fmt.Errorf(ocmToken)
-		}
+	if err != nil {
+		return "", fmt.Errorf("ocm token failed: %v", CommandError(err))
 	}
-	return ocmToken, err
+	return strings.TrimSpace(string(out)), nil
 }
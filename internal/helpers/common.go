@@ -17,7 +17,9 @@ limitations under the License.
 package internal
 
 import (
+	"fmt"
 	"net"
+	"time"
 )
 
 // Gets free/unused network ports
@@ -40,3 +42,17 @@ func GetFreePorts(numPorts int) ([]int, error) {
 	return ports, nil
 
 }
+
+// Formats a duration like "45s", "12m", "3h", "2d".
+func FormatDuration(d time.Duration) string {
+	switch {
+	case d < time.Minute:
+		return fmt.Sprintf("%ds", int(d.Seconds()))
+	case d < time.Hour:
+		return fmt.Sprintf("%dm", int(d.Minutes()))
+	case d < 48*time.Hour:
+		return fmt.Sprintf("%dh", int(d.Hours()))
+	default:
+		return fmt.Sprintf("%dd", int(d.Hours()/24))
+	}
+}
@@ -1,15 +1,86 @@
 package internal
 
 import (
+	"errors"
 	"fmt"
 	"io"
+	"net/url"
 	"os"
 	"os/exec"
+	"strings"
+	"syscall"
 
 	gocmd "github.com/go-cmd/cmd"
 	logger "github.com/sirupsen/logrus"
 )
 
+// Placeholder of redacted secrets in logged command args.
+const redacted = "<redacted>"
+
+// Flags whose next argument is a secret.
+var secretFlags = map[string]bool{
+	"--token":                true,
+	"-k8s-auth-bearer-token": true,
+}
+
+// Redacts the secrets in command args before logging them. Explicit secret
+// values, the values of secret flags and of environment variables whose name
+// contains TOKEN, PASSWORD or SECRET, and URL passwords are redacted.
+func RedactArgs(args []string, secrets ...string) []string {
+	redactedArgs := make([]string, 0, len(args))
+	redactNext := false
+
+	for _, arg := range args {
+		if redactNext {
+			redactedArgs = append(redactedArgs, redacted)
+			redactNext = false
+			continue
+		}
+
+		redactNext = secretFlags[arg]
+
+		if kv := strings.SplitN(arg, "=", 2); len(kv) == 2 {
+			if secretFlags[kv[0]] || isSecretName(kv[0]) {
+				arg = fmt.Sprintf("%s=%s", kv[0], redacted)
+			} else {
+				arg = fmt.Sprintf("%s=%s", kv[0], redactUrlPassword(kv[1]))
+			}
+		} else {
+			arg = redactUrlPassword(arg)
+		}
+
+		for _, secret := range secrets {
+			secret = strings.TrimSpace(secret)
+			if len(secret) > 0 {
+				arg = strings.ReplaceAll(arg, secret, redacted)
+			}
+		}
+		redactedArgs = append(redactedArgs, arg)
+	}
+	return redactedArgs
+}
+
+// Checks if an environment variable name refers to a secret value rather than
+// to a file holding it.
+func isSecretName(name string) bool {
+	name = strings.ToUpper(name)
+	if strings.HasSuffix(name, "_FILE") || strings.HasSuffix(name, "_PATH") {
+		return false
+	}
+	return strings.Contains(name, "TOKEN") || strings.Contains(name, "PASSWORD") || strings.Contains(name, "SECRET")
+}
+
+func redactUrlPassword(value string) string {
+	u, err := url.Parse(value)
+	if err != nil || u.User == nil {
+		return value
+	}
+	if password, ok := u.User.Password(); ok {
+		return strings.Replace(value, fmt.Sprintf(":%s@", password), fmt.Sprintf(":%s@", redacted), 1)
+	}
+	return value
+}
+
 func RunCommandBackground(cmdName string, cmdArgs []string, envVars [][]string) error {
 	cmd := exec.Command(cmdName, cmdArgs...)
 	for _, env := range envVars {
@@ -33,12 +104,81 @@ func RunCommandBackground(cmdName string, cmdArgs []string, envVars [][]string)
 	return nil
 }
 
+// Runs a command with additional environment variables, streaming its output,
+// and waits for it.
+func RunCommandWithEnv(cmdName string, cmdArgs []string, envVars [][]string) error {
+	cmd := exec.Command(cmdName, cmdArgs...)
+	cmd.Env = os.Environ()
+	for _, env := range envVars {
+		cmd.Env = append(cmd.Env, fmt.Sprintf("%s=%s", env[0], env[1]))
+	}
+	cmd.Stdin = os.Stdin
+	cmd.Stdout = os.Stdout
+	cmd.Stderr = os.Stderr
+	return cmd.Run()
+}
+
+// Starts a command in a new session that outlives the current process. Its
+// output is appended to logPath.
+func RunCommandDetached(cmdName string, cmdArgs []string, logPath string) error {
+	logFile, err := os.OpenFile(logPath, os.O_APPEND|os.O_CREATE|os.O_WRONLY, 0600)
+	if err != nil {
+		return err
+	}
+	defer logFile.Close()
+
+	cmd := exec.Command(cmdName, cmdArgs...)
+	cmd.Stdout = logFile
+	cmd.Stderr = logFile
+	cmd.SysProcAttr = &syscall.SysProcAttr{Setsid: true}
+
+	err = cmd.Start()
+	if err != nil {
+		return err
+	}
+	return cmd.Process.Release()
+}
+
 func RunCommandOutput(cmdName string, cmdArgs ...string) ([]byte, error) {
 	// log.Printf("Running command: %s %s\n", cmdName, cmdArgs)
 	cmd := exec.Command(cmdName, cmdArgs...)
 	return cmd.Output()
 }
 
+// Runs a command in a directory and returns its combined output.
+func RunCommandInDir(dir string, cmdName string, cmdArgs ...string) ([]byte, error) {
+	cmd := exec.Command(cmdName, cmdArgs...)
+	cmd.Dir = dir
+	return cmd.CombinedOutput()
+}
+
+// Runs a command with additional environment variables and returns its output.
+func RunCommandOutputWithEnv(cmdName string, envVars [][]string, cmdArgs ...string) ([]byte, error) {
+	cmd := exec.Command(cmdName, cmdArgs...)
+	cmd.Env = os.Environ()
+	for _, env := range envVars {
+		envVar := fmt.Sprintf("%s=%s", env[0], env[1])
+		cmd.Env = append(cmd.Env, envVar)
+	}
+	return cmd.Output()
+}
+
+// Runs a command writing input to its stdin and returns its output.
+func RunCommandWithInput(input string, cmdName string, cmdArgs ...string) ([]byte, error) {
+	cmd := exec.Command(cmdName, cmdArgs...)
+	cmd.Stdin = strings.NewReader(input)
+	return cmd.Output()
+}
+
+// Adds the stderr of a failed command to its error.
+func CommandError(err error) error {
+	var exitErr *exec.ExitError
+	if errors.As(err, &exitErr) && len(exitErr.Stderr) > 0 {
+		return fmt.Errorf("%v: %s", err, strings.TrimSpace(string(exitErr.Stderr)))
+	}
+	return err
+}
+
 func RunCommandPipeStdin(cmdName string, cmdArgs ...string) ([]byte, error) {
 	// log.Printf("Running command: %s %s\n", cmdName, cmdArgs)
 	cmd := exec.Command(cmdName, cmdArgs...)
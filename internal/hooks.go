@@ -0,0 +1,91 @@
+/*
+Copyright © 2023 Jose Cueto
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package internal
+
+// Hook events that plugins run on (plugins.runOn).
+const (
+	HookPreLogin              = "preLogin"
+	HookOcmLoginSuccess       = "ocmLoginSuccess"
+	HookBackplaneLoginSuccess = "backplaneLoginSuccess"
+	HookShellStart            = "shellStart"
+	HookNamespaceChange       = "namespaceChange"
+	HookShellExit             = "shellExit"
+	HookContainerStop         = "containerStop"
+)
+
+// Former name of the backplaneLoginSuccess event.
+const HookOcmBackplaneLoginSuccess = "ocmBackplaneLoginSuccess"
+
+// HookEvent describes where and how the plugins of an event run.
+type HookEvent struct {
+	Name string
+	// Runs on the host instead of in the workspace container.
+	OnHost bool
+	// The plugins are started in the background (e.g. long running port
+	// forwards) instead of being waited for.
+	Background bool
+}
+
+var HookEvents = []HookEvent{
+	{Name: HookPreLogin, OnHost: true},
+	{Name: HookOcmLoginSuccess, Background: true},
+	{Name: HookBackplaneLoginSuccess, Background: true},
+	{Name: HookShellStart},
+	{Name: HookNamespaceChange},
+	{Name: HookShellExit},
+	{Name: HookContainerStop},
+}
+
+// PluginRunOnValues are the workspace execution points a plugin can run on.
+var PluginRunOnValues = append(getHookEventNames(), HookOcmBackplaneLoginSuccess)
+
+func getHookEventNames() []string {
+	names := []string{}
+	for _, event := range HookEvents {
+		names = append(names, event.Name)
+	}
+	return names
+}
+
+// Gets a hook event by name or alias.
+func GetHookEvent(name string) (HookEvent, bool) {
+	if name == HookOcmBackplaneLoginSuccess {
+		name = HookBackplaneLoginSuccess
+	}
+	for _, event := range HookEvents {
+		if event.Name == name {
+			return event, true
+		}
+	}
+	return HookEvent{}, false
+}
+
+// Gets the plugins that run on a hook event in their declared order.
+func (c *OcmWorkspaceConfig) GetHookPlugins(event string) []Plugin {
+	hookEvent, ok := GetHookEvent(event)
+	if !ok {
+		return nil
+	}
+
+	plugins := []Plugin{}
+	for _, plug := range c.Plugins {
+		if runOn, ok := GetHookEvent(plug.RunOn); ok && runOn.Name == hookEvent.Name {
+			plugins = append(plugins, plug)
+		}
+	}
+	return plugins
+}
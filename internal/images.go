@@ -0,0 +1,200 @@
+/*
+Copyright © 2023 Jose Cueto
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package internal
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	pkgIntHelper "ocm-workspace/internal/helpers"
+)
+
+// ConsoleImage records an OpenShift console image pulled for a cluster.
+type ConsoleImage struct {
+	Image            string    `json:"image" yaml:"image"`
+	Digest           string    `json:"digest" yaml:"digest"`
+	OpenShiftVersion string    `json:"openshiftVersion" yaml:"openshiftVersion"`
+	LastUsed         time.Time `json:"lastUsed" yaml:"lastUsed"`
+}
+
+// ConsoleImageCache records which console images map to which OpenShift
+// versions so that they are reused across console launches.
+type ConsoleImageCache struct {
+	path   string
+	Images []ConsoleImage `json:"images"`
+}
+
+type imageInspect struct {
+	ID          string   `json:"Id"`
+	Digest      string   `json:"Digest"`
+	RepoDigests []string `json:"RepoDigests"`
+}
+
+// Loads the console image cache from the state directory.
+func NewConsoleImageCache() (*ConsoleImageCache, error) {
+	stateDir, err := GetStateDir()
+	if err != nil {
+		return nil, err
+	}
+
+	cache := &ConsoleImageCache{
+		path: filepath.Join(stateDir, "console-images.json"),
+	}
+
+	content, err := os.ReadFile(cache.path)
+	if errors.Is(err, os.ErrNotExist) {
+		return cache, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	err = json.Unmarshal(content, cache)
+	if err != nil {
+		return nil, fmt.Errorf("failed to unmarshal %s: %v", cache.path, err)
+	}
+	return cache, nil
+}
+
+// Gets the digest of a local image, an error if the image is not present.
+// Images without a repo digest (e.g. built locally) fall back to their image
+// ID, which is not a manifest digest and must not be used to verify images.
+func GetLocalImageDigest(ce ContainerEngine, image string) (string, error) {
+	out, err := pkgIntHelper.RunCommandOutput(ce.GetExecName(), ce.GetImageInspectArgs(image)...)
+	if err != nil {
+		return "", fmt.Errorf("image %s is not present: %v", image, err)
+	}
+
+	var inspected []imageInspect
+	err = json.Unmarshal(out, &inspected)
+	if err != nil {
+		return "", fmt.Errorf("failed to unmarshal image inspect output: %v", err)
+	}
+	if len(inspected) == 0 {
+		return "", fmt.Errorf("image %s is not present", image)
+	}
+
+	img := inspected[0]
+	if len(img.Digest) > 0 {
+		return img.Digest, nil
+	}
+	for _, repoDigest := range img.RepoDigests {
+		if idx := strings.LastIndex(repoDigest, "@"); idx >= 0 {
+			return repoDigest[idx+1:], nil
+		}
+	}
+	return img.ID, nil
+}
+
+// Records the use of a console image.
+func (cic *ConsoleImageCache) Record(image string, digest string, openshiftVersion string) error {
+	entry := ConsoleImage{
+		Image:            image,
+		Digest:           digest,
+		OpenShiftVersion: openshiftVersion,
+		LastUsed:         time.Now(),
+	}
+
+	for idx, img := range cic.Images {
+		if img.Image == image {
+			cic.Images[idx] = entry
+			return cic.save()
+		}
+	}
+	cic.Images = append(cic.Images, entry)
+	return cic.save()
+}
+
+// Removes the record of a console image.
+func (cic *ConsoleImageCache) Remove(image string) error {
+	images := []ConsoleImage{}
+	for _, img := range cic.Images {
+		if img.Image != image {
+			images = append(images, img)
+		}
+	}
+	cic.Images = images
+	return cic.save()
+}
+
+// Gets the recorded console images sorted by OpenShift version.
+func (cic *ConsoleImageCache) GetImages() []ConsoleImage {
+	images := append([]ConsoleImage{}, cic.Images...)
+	sort.SliceStable(images, func(i, j int) bool {
+		return compareOpenShiftVersions(images[i].OpenShiftVersion, images[j].OpenShiftVersion) < 0
+	})
+	return images
+}
+
+// Compares two OpenShift versions (e.g. 4.9.12 and 4.10.3) by their numeric
+// major, minor and patch parts. Pre-release suffixes and unparsable versions
+// are compared as strings.
+func compareOpenShiftVersions(a string, b string) int {
+	aParts, aOk := parseOpenShiftVersion(a)
+	bParts, bOk := parseOpenShiftVersion(b)
+	if aOk && bOk {
+		for idx := range aParts {
+			if aParts[idx] != bParts[idx] {
+				if aParts[idx] < bParts[idx] {
+					return -1
+				}
+				return 1
+			}
+		}
+	} else if aOk != bOk {
+		// Unknown versions first
+		if bOk {
+			return -1
+		}
+		return 1
+	}
+	return strings.Compare(a, b)
+}
+
+// Parses the major, minor and patch numbers of an OpenShift version, the
+// patch number defaults to 0.
+func parseOpenShiftVersion(version string) ([3]int, bool) {
+	parts := [3]int{}
+	core := strings.SplitN(strings.SplitN(version, "+", 2)[0], "-", 2)[0]
+	fields := strings.Split(core, ".")
+	if len(fields) < 2 || len(fields) > 3 {
+		return parts, false
+	}
+	for idx, field := range fields {
+		number, err := strconv.Atoi(field)
+		if err != nil || number < 0 {
+			return parts, false
+		}
+		parts[idx] = number
+	}
+	return parts, true
+}
+
+func (cic *ConsoleImageCache) save() error {
+	content, err := json.MarshalIndent(cic, "", "  ")
+	if err != nil {
+		return err
+	}
+	return os.WriteFile(cic.path, content, 0600)
+}
@@ -0,0 +1,63 @@
+/*
+Copyright © 2023 Jose Cueto
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package internal
+
+import (
+	"testing"
+)
+
+func TestGetImagesSortsByOpenShiftVersion(t *testing.T) {
+	cache := &ConsoleImageCache{Images: []ConsoleImage{
+		{Image: "a", OpenShiftVersion: "4.10.3"},
+		{Image: "b", OpenShiftVersion: "4.9.12"},
+		{Image: "c", OpenShiftVersion: "4.10.20"},
+		{Image: "d", OpenShiftVersion: ""},
+		{Image: "e", OpenShiftVersion: "4.14.0-rc.1"},
+		{Image: "f", OpenShiftVersion: "4.9"},
+		{Image: "g", OpenShiftVersion: "4.10.3"},
+	}}
+
+	expected := []string{"d", "f", "b", "a", "g", "c", "e"}
+	images := cache.GetImages()
+	for idx, img := range images {
+		if img.Image != expected[idx] {
+			t.Fatalf("expected order %v, got %v", expected, images)
+		}
+	}
+}
+
+func TestCompareOpenShiftVersions(t *testing.T) {
+	tests := []struct {
+		a, b     string
+		expected int
+	}{
+		{"4.9.0", "4.10.0", -1},
+		{"4.10.0", "4.9.0", 1},
+		{"4.10.2", "4.10.10", -1},
+		{"4.10", "4.10.0", -1},
+		{"4.12.1", "4.12.1", 0},
+		{"", "4.1.0", -1},
+		{"4.1.0", "unknown", 1},
+		{"4.13.0-rc.1", "4.13.0-rc.2", -1},
+	}
+
+	for _, test := range tests {
+		if actual := compareOpenShiftVersions(test.a, test.b); actual != test.expected {
+			t.Errorf("compareOpenShiftVersions(%q, %q) = %d, expected %d", test.a, test.b, actual, test.expected)
+		}
+	}
+}
@@ -0,0 +1,205 @@
+/*
+Copyright © 2023 Jose Cueto
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package internal
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"syscall"
+	"time"
+
+	pkgIntHelper "ocm-workspace/internal/helpers"
+)
+
+// Maximum number of free port lookups before giving up on a reservation.
+const maxPortLeaseAttempts = 100
+
+// PortLeases records the host ports allocated to workspace containers so that
+// they are not handed out to another workspace while the container exists.
+// The leases file is locked from loading the leases until Close, so that
+// concurrent commands do not lease the same ports or drop each other's leases.
+type PortLeases struct {
+	path string
+	lock *os.File
+	// Leased ports by owner (container name)
+	Leases map[string][]int `json:"leases"`
+	// Time of the last reservation by owner
+	Reserved map[string]time.Time `json:"reserved,omitempty"`
+}
+
+// Loads and locks the port leases of the state directory. The lock is
+// released by Close (or when the process exits).
+func NewPortLeases() (*PortLeases, error) {
+	stateDir, err := GetStateDir()
+	if err != nil {
+		return nil, err
+	}
+
+	pl := &PortLeases{
+		path:     filepath.Join(stateDir, "port-leases.json"),
+		Leases:   map[string][]int{},
+		Reserved: map[string]time.Time{},
+	}
+
+	pl.lock, err = os.OpenFile(pl.path+".lock", os.O_CREATE|os.O_RDWR, 0600)
+	if err != nil {
+		return nil, err
+	}
+	err = syscall.Flock(int(pl.lock.Fd()), syscall.LOCK_EX)
+	if err != nil {
+		pl.lock.Close()
+		return nil, fmt.Errorf("failed to lock %s: %v", pl.path, err)
+	}
+
+	content, err := os.ReadFile(pl.path)
+	if errors.Is(err, os.ErrNotExist) {
+		return pl, nil
+	}
+	if err != nil {
+		pl.Close()
+		return nil, err
+	}
+
+	err = json.Unmarshal(content, pl)
+	if err != nil {
+		pl.Close()
+		return nil, fmt.Errorf("failed to unmarshal %s: %v", pl.path, err)
+	}
+	if pl.Leases == nil {
+		pl.Leases = map[string][]int{}
+	}
+	if pl.Reserved == nil {
+		pl.Reserved = map[string]time.Time{}
+	}
+	return pl, nil
+}
+
+// Releases the lock of the port leases, the leases must not be modified
+// afterwards.
+func (pl *PortLeases) Close() error {
+	if pl.lock == nil {
+		return nil
+	}
+	err := pl.lock.Close()
+	pl.lock = nil
+	return err
+}
+
+// Reserves free host ports that are not leased by another owner.
+func (pl *PortLeases) Reserve(owner string, numPorts int) ([]int, error) {
+	if pl.lock == nil {
+		return nil, errors.New("port leases are closed")
+	}
+
+	leased := map[int]bool{}
+	for _, ports := range pl.Leases {
+		for _, port := range ports {
+			leased[port] = true
+		}
+	}
+
+	reserved := []int{}
+	for attempt := 0; len(reserved) < numPorts; attempt++ {
+		if attempt >= maxPortLeaseAttempts {
+			return nil, fmt.Errorf("failed to reserve %d free ports", numPorts)
+		}
+
+		ports, err := pkgIntHelper.GetFreePorts(numPorts - len(reserved))
+		if err != nil {
+			return nil, err
+		}
+		for _, port := range ports {
+			if leased[port] {
+				continue
+			}
+			leased[port] = true
+			reserved = append(reserved, port)
+		}
+	}
+
+	pl.Leases[owner] = append(pl.Leases[owner], reserved...)
+	pl.Reserved[owner] = time.Now()
+	return reserved, pl.save()
+}
+
+// Releases the ports leased by the owners.
+func (pl *PortLeases) Release(owners ...string) error {
+	if pl.lock == nil {
+		return errors.New("port leases are closed")
+	}
+
+	for _, owner := range owners {
+		delete(pl.Leases, owner)
+		delete(pl.Reserved, owner)
+	}
+	return pl.save()
+}
+
+// Gets the owners (container names) that hold port leases.
+func (pl *PortLeases) Owners() []string {
+	owners := []string{}
+	for owner := range pl.Leases {
+		owners = append(owners, owner)
+	}
+	sort.Strings(owners)
+	return owners
+}
+
+// Gets the owners whose containers do not exist and whose last reservation
+// is older than the grace period. Recent leases may belong to a login that
+// has not created its container yet, leases without a reservation time (e.g.
+// recorded by older versions) are orphans.
+func (pl *PortLeases) GetOrphans(existing map[string]bool, gracePeriod time.Duration) []string {
+	orphans := []string{}
+	for _, owner := range pl.Owners() {
+		if !existing[owner] && time.Since(pl.Reserved[owner]) > gracePeriod {
+			orphans = append(orphans, owner)
+		}
+	}
+	return orphans
+}
+
+// Writes the leases to a temporary file that replaces the leases file, so
+// that the file is never left partially written.
+func (pl *PortLeases) save() error {
+	content, err := json.MarshalIndent(pl, "", "  ")
+	if err != nil {
+		return err
+	}
+
+	tmpFile, err := os.CreateTemp(filepath.Dir(pl.path), ".port-leases-*.json")
+	if err != nil {
+		return err
+	}
+	defer os.Remove(tmpFile.Name())
+
+	_, err = tmpFile.Write(content)
+	if err == nil {
+		err = tmpFile.Sync()
+	}
+	if closeErr := tmpFile.Close(); err == nil {
+		err = closeErr
+	}
+	if err != nil {
+		return err
+	}
+	return os.Rename(tmpFile.Name(), pl.path)
+}
@@ -0,0 +1,80 @@
+/*
+Copyright © 2023 Jose Cueto
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package internal
+
+import (
+	"reflect"
+	"testing"
+	"time"
+)
+
+func TestPortLeasesGetOrphans(t *testing.T) {
+	t.Setenv("HOME", t.TempDir())
+
+	portLeases, err := NewPortLeases()
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer portLeases.Close()
+
+	for _, owner := range []string{"ow-running", "ow-removed", "ow-starting", "ow-legacy"} {
+		if _, err := portLeases.Reserve(owner, 1); err != nil {
+			t.Fatal(err)
+		}
+	}
+	portLeases.Reserved["ow-removed"] = time.Now().Add(-time.Hour)
+	delete(portLeases.Reserved, "ow-legacy")
+
+	orphans := portLeases.GetOrphans(map[string]bool{"ow-running": true}, 5*time.Minute)
+	expected := []string{"ow-legacy", "ow-removed"}
+	if !reflect.DeepEqual(orphans, expected) {
+		t.Fatalf("expected orphans %v, got %v", expected, orphans)
+	}
+}
+
+func TestPortLeasesPersist(t *testing.T) {
+	t.Setenv("HOME", t.TempDir())
+
+	portLeases, err := NewPortLeases()
+	if err != nil {
+		t.Fatal(err)
+	}
+	ports, err := portLeases.Reserve("ow-a", 2)
+	if err != nil {
+		t.Fatal(err)
+	}
+	portLeases.Close()
+
+	if _, err := portLeases.Reserve("ow-b", 1); err == nil {
+		t.Fatal("expected closed port leases to refuse reservations")
+	}
+
+	portLeases, err = NewPortLeases()
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer portLeases.Close()
+	if !reflect.DeepEqual(portLeases.Leases["ow-a"], ports) {
+		t.Fatalf("expected leased ports %v, got %v", ports, portLeases.Leases["ow-a"])
+	}
+	if portLeases.Reserved["ow-a"].IsZero() {
+		t.Fatal("expected the reservation time to be recorded")
+	}
+	if orphans := portLeases.GetOrphans(nil, 5*time.Minute); len(orphans) != 0 {
+		t.Fatalf("expected a recent lease not to be an orphan, got %v", orphans)
+	}
+}
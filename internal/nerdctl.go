@@ -0,0 +1,54 @@
+/*
+Copyright © 2023 Jose Cueto
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+package internal
+
+// Volume map attributes understood by nerdctl's -v flag. SELinux relabeling
+// (z, Z) is not supported by nerdctl.
+var nerdctlVolMapAttrs = map[string]bool{
+	"ro":       true,
+	"rw":       true,
+	"rro":      true,
+	"shared":   true,
+	"slave":    true,
+	"private":  true,
+	"rshared":  true,
+	"rslave":   true,
+	"rprivate": true,
+}
+
+type nerdctl struct {
+	ceArgs
+}
+
+func NewNerdctl() *nerdctl {
+	return &nerdctl{}
+}
+
+func (n *nerdctl) ToVolMapArgs() []string {
+	return n.toVolMapArgs(nerdctlVolMapAttrs)
+}
+
+func (n *nerdctl) GetRunArgs(containerName string, entryPoint string, image string, entryPointArgs ...string) []string {
+	return n.getRunArgs(n.ToVolMapArgs(), containerName, entryPoint, image, entryPointArgs...)
+}
+
+func (n *nerdctl) GetRegistryAuthEnvVars(authFile string) [][]string {
+	return dockerRegistryAuthEnvVars(authFile)
+}
+
+func (n *nerdctl) GetExecName() string {
+	return "nerdctl"
+}
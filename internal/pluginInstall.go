@@ -0,0 +1,533 @@
+/*
+Copyright © 2023 Jose Cueto
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package internal
+
+import (
+	"archive/tar"
+	"compress/gzip"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strings"
+
+	"github.com/google/uuid"
+	logger "github.com/sirupsen/logrus"
+	"gopkg.in/yaml.v3"
+
+	pkgIntHelper "ocm-workspace/internal/helpers"
+)
+
+// Kinds of plugin install sources.
+const (
+	PluginSourcePath    = "path"
+	PluginSourceTarball = "tarball"
+	PluginSourceGit     = "git"
+	PluginSourceOci     = "oci"
+)
+
+// PluginImageDir is the directory of an OCI plugin image holding the plugin.
+const PluginImageDir = "/plugin"
+
+var (
+	sha256Regexp    = regexp.MustCompile(`^[0-9a-f]{64}$`)
+	gitCommitRegexp = regexp.MustCompile(`^([0-9a-f]{40}|[0-9a-f]{64})$`)
+)
+
+// PluginInstallOptions configures a plugin install.
+type PluginInstallOptions struct {
+	// Expected SHA-256 checksum of a tarball or digest of an OCI image.
+	Sha256 string
+	// Expected commit ID of a git source.
+	Commit string
+	// Install remote sources without verifying them.
+	Insecure bool
+	// Replace an installed plugin of the same name.
+	Force bool
+	// Container engine pulling OCI images.
+	Ce ContainerEngine
+}
+
+// Gets the kind of a plugin install source and the source without its
+// kind prefix. Sources are a local directory, a tarball (.tar, .tar.gz or
+// .tgz) path or http(s) URL, a git URL (git+<url> or ending with .git,
+// optionally with #<ref>) or an OCI image (oci://<image>).
+func GetPluginSourceKind(source string) (string, string, error) {
+	switch {
+	case strings.HasPrefix(source, "oci://"):
+		return PluginSourceOci, strings.TrimPrefix(source, "oci://"), nil
+	case strings.HasPrefix(source, "git+"):
+		return PluginSourceGit, strings.TrimPrefix(source, "git+"), nil
+	}
+
+	repo, _, _ := strings.Cut(source, "#")
+	if strings.HasSuffix(repo, ".git") {
+		return PluginSourceGit, source, nil
+	}
+	for _, suffix := range []string{".tar", ".tar.gz", ".tgz"} {
+		if strings.HasSuffix(source, suffix) {
+			return PluginSourceTarball, source, nil
+		}
+	}
+	if info, err := os.Stat(source); err == nil && info.IsDir() {
+		return PluginSourcePath, source, nil
+	}
+	return "", "", fmt.Errorf(
+		"plugin source %s is not a directory, tarball (.tar, .tar.gz, .tgz), git URL (git+<url>, *.git) or OCI image (oci://<image>)",
+		source,
+	)
+}
+
+// Installs a plugin from a source into the plugins directory. Go plugins
+// whose executable is missing are built with go build.
+func InstallPlugin(source string, opts PluginInstallOptions) (*PluginManifest, error) {
+	kind, location, err := GetPluginSourceKind(source)
+	if err != nil {
+		return nil, err
+	}
+	err = checkPluginVerification(kind, location, opts)
+	if err != nil {
+		return nil, err
+	}
+
+	pluginsDir, err := GetPluginsDir()
+	if err != nil {
+		return nil, err
+	}
+	err = os.MkdirAll(pluginsDir, 0700)
+	if err != nil {
+		return nil, err
+	}
+
+	// Fetched into a hidden directory next to the installed plugins so that
+	// it can be moved into place
+	workDir, err := os.MkdirTemp(pluginsDir, ".install-")
+	if err != nil {
+		return nil, err
+	}
+	defer os.RemoveAll(workDir)
+
+	srcDir := filepath.Join(workDir, "src")
+	switch kind {
+	case PluginSourcePath:
+		err = copyDir(location, srcDir)
+	case PluginSourceTarball:
+		err = fetchPluginTarball(location, workDir, srcDir, opts.Sha256)
+	case PluginSourceGit:
+		err = fetchPluginGit(location, srcDir, opts.Commit)
+	case PluginSourceOci:
+		err = fetchPluginImage(opts.Ce, location, srcDir, opts.Sha256)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to fetch plugin %s: %v", source, err)
+	}
+
+	pluginDir, err := findPluginDir(srcDir)
+	if err != nil {
+		return nil, err
+	}
+	err = buildPlugin(pluginDir)
+	if err != nil {
+		return nil, err
+	}
+	manifest, err := LoadPluginManifest(pluginDir)
+	if err != nil {
+		return nil, err
+	}
+
+	installDir := filepath.Join(pluginsDir, manifest.Name)
+	if _, err := os.Stat(installDir); err == nil {
+		if !opts.Force {
+			return nil, fmt.Errorf("plugin %s is already installed in %s, use --force to replace it", manifest.Name, installDir)
+		}
+		err = os.RemoveAll(installDir)
+		if err != nil {
+			return nil, err
+		}
+	}
+	err = os.Rename(pluginDir, installDir)
+	if err != nil {
+		return nil, err
+	}
+	return LoadPluginManifest(installDir)
+}
+
+// Checks that the plugin will be verified: remote tarballs by their SHA-256
+// checksum, OCI images by their digest and git sources by their commit ID.
+// Only local sources and insecure installs are not verified.
+func checkPluginVerification(kind string, location string, opts PluginInstallOptions) error {
+	if len(opts.Sha256) > 0 && !sha256Regexp.MatchString(normalizeSha256(opts.Sha256)) {
+		return fmt.Errorf("%s is not a SHA-256 checksum (64 hexadecimal characters)", opts.Sha256)
+	}
+	if len(opts.Commit) > 0 && !gitCommitRegexp.MatchString(strings.ToLower(opts.Commit)) {
+		return fmt.Errorf("%s is not a full git commit ID", opts.Commit)
+	}
+	if len(opts.Sha256) > 0 && kind != PluginSourceTarball && kind != PluginSourceOci {
+		return errors.New("checksums are only verified for tarballs and OCI images")
+	}
+	if len(opts.Commit) > 0 && kind != PluginSourceGit {
+		return errors.New("commit IDs are only verified for git sources")
+	}
+
+	verified := true
+	switch kind {
+	case PluginSourceTarball:
+		verified = !isRemoteUrl(location) || len(opts.Sha256) > 0
+	case PluginSourceOci:
+		verified = len(opts.Sha256) > 0 || strings.Contains(location, "@sha256:")
+	case PluginSourceGit:
+		verified = len(opts.Commit) > 0
+	}
+	if verified {
+		return nil
+	}
+	if !opts.Insecure {
+		switch kind {
+		case PluginSourceTarball:
+			return fmt.Errorf("remote tarball %s requires --sha256 <checksum> (or --insecure)", location)
+		case PluginSourceOci:
+			return fmt.Errorf("OCI image %s requires --sha256 <digest> or an @sha256:<digest> reference (or --insecure)", location)
+		default:
+			return fmt.Errorf("git source %s requires --commit <commit ID> (or --insecure)", location)
+		}
+	}
+	logger.Warnf("Installing plugin from %s without verifying it", location)
+	return nil
+}
+
+func isRemoteUrl(location string) bool {
+	return strings.HasPrefix(location, "http://") || strings.HasPrefix(location, "https://")
+}
+
+// Gets the directory holding the plugin manifest, the fetched directory or
+// its only subdirectory (e.g. the top directory of a tarball).
+func findPluginDir(srcDir string) (string, error) {
+	if _, err := os.Stat(filepath.Join(srcDir, PluginManifestFile)); err == nil {
+		return srcDir, nil
+	}
+
+	entries, err := os.ReadDir(srcDir)
+	if err != nil {
+		return "", err
+	}
+	if len(entries) == 1 && entries[0].IsDir() {
+		subDir := filepath.Join(srcDir, entries[0].Name())
+		if _, err := os.Stat(filepath.Join(subDir, PluginManifestFile)); err == nil {
+			return subDir, nil
+		}
+	}
+	return "", fmt.Errorf("the plugin has no %s", PluginManifestFile)
+}
+
+// Builds the executable of a plugin that ships Go source (a go.mod) but not
+// the executable.
+func buildPlugin(pluginDir string) error {
+	content, err := os.ReadFile(filepath.Join(pluginDir, PluginManifestFile))
+	if err != nil {
+		return err
+	}
+	manifest := &PluginManifest{}
+	err = yaml.Unmarshal(content, manifest)
+	if err != nil {
+		return fmt.Errorf("failed to unmarshal %s: %v", PluginManifestFile, err)
+	}
+	executable := manifest.Executable
+	if len(executable) == 0 {
+		executable = manifest.Name
+	}
+
+	if _, err := os.Stat(filepath.Join(pluginDir, executable)); err == nil {
+		return nil
+	}
+	if _, err := os.Stat(filepath.Join(pluginDir, "go.mod")); err != nil {
+		return nil
+	}
+
+	logger.Infof("Building plugin %s", manifest.Name)
+	out, err := pkgIntHelper.RunCommandInDir(pluginDir, "go", "build", "-o", executable, ".")
+	if err != nil {
+		return fmt.Errorf("failed to build plugin %s: %v: %s", manifest.Name, err, strings.TrimSpace(string(out)))
+	}
+	return nil
+}
+
+func fetchPluginTarball(location string, workDir string, srcDir string, checksum string) error {
+	tarball := location
+	if isRemoteUrl(location) {
+		tarball = filepath.Join(workDir, filepath.Base(location))
+		err := downloadFile(location, tarball)
+		if err != nil {
+			return err
+		}
+	}
+
+	if len(checksum) > 0 {
+		err := verifySha256(tarball, checksum)
+		if err != nil {
+			return err
+		}
+	}
+	return extractTarball(tarball, srcDir)
+}
+
+func downloadFile(url string, path string) error {
+	resp, err := http.Get(url)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return fmt.Errorf("failed to download %s: %s", url, resp.Status)
+	}
+
+	file, err := os.OpenFile(path, os.O_CREATE|os.O_WRONLY|os.O_TRUNC, 0600)
+	if err != nil {
+		return err
+	}
+	defer file.Close()
+	_, err = io.Copy(file, resp.Body)
+	return err
+}
+
+func verifySha256(path string, checksum string) error {
+	file, err := os.Open(path)
+	if err != nil {
+		return err
+	}
+	defer file.Close()
+
+	hash := sha256.New()
+	_, err = io.Copy(hash, file)
+	if err != nil {
+		return err
+	}
+	actual := hex.EncodeToString(hash.Sum(nil))
+	if actual != normalizeSha256(checksum) {
+		return fmt.Errorf("checksum mismatch of %s: expected sha256:%s, got sha256:%s", path, normalizeSha256(checksum), actual)
+	}
+	return nil
+}
+
+func normalizeSha256(checksum string) string {
+	return strings.ToLower(strings.TrimPrefix(checksum, "sha256:"))
+}
+
+// Extracts a (gzip compressed) tarball. Entries outside of the destination
+// and links are refused.
+func extractTarball(tarball string, dst string) error {
+	file, err := os.Open(tarball)
+	if err != nil {
+		return err
+	}
+	defer file.Close()
+
+	var reader io.Reader = file
+	if strings.HasSuffix(tarball, ".gz") || strings.HasSuffix(tarball, ".tgz") {
+		gzipReader, err := gzip.NewReader(file)
+		if err != nil {
+			return err
+		}
+		defer gzipReader.Close()
+		reader = gzipReader
+	}
+
+	tarReader := tar.NewReader(reader)
+	for {
+		header, err := tarReader.Next()
+		if err == io.EOF {
+			return nil
+		}
+		if err != nil {
+			return err
+		}
+
+		target := filepath.Join(dst, header.Name)
+		if target != dst && !strings.HasPrefix(target, dst+string(os.PathSeparator)) {
+			return fmt.Errorf("tarball entry %s is outside of the plugin directory", header.Name)
+		}
+
+		switch header.Typeflag {
+		case tar.TypeDir:
+			err = os.MkdirAll(target, 0755)
+		case tar.TypeReg:
+			err = writeFile(target, tarReader, header.FileInfo().Mode().Perm())
+		case tar.TypeXGlobalHeader:
+			// e.g. the commit ID of git archive
+		default:
+			err = fmt.Errorf("tarball entry %s is not a file or directory", header.Name)
+		}
+		if err != nil {
+			return err
+		}
+	}
+}
+
+// Clones a git source, a commit ID is checked out (if there is no ref) and
+// verified.
+func fetchPluginGit(location string, srcDir string, commit string) error {
+	repo, ref, _ := strings.Cut(location, "#")
+	cloneArgs := []string{"clone", "--quiet"}
+	if len(ref) > 0 {
+		cloneArgs = append(cloneArgs, "--branch", ref)
+	}
+	if len(commit) == 0 || len(ref) > 0 {
+		cloneArgs = append(cloneArgs, "--depth", "1")
+	}
+	cloneArgs = append(cloneArgs, repo, srcDir)
+
+	out, err := pkgIntHelper.RunCommandInDir("", "git", cloneArgs...)
+	if err != nil {
+		return fmt.Errorf("git clone failed: %v: %s", err, strings.TrimSpace(string(out)))
+	}
+
+	if len(commit) > 0 {
+		commit = strings.ToLower(commit)
+		if len(ref) == 0 {
+			out, err = pkgIntHelper.RunCommandInDir(srcDir, "git", "checkout", "--quiet", commit)
+			if err != nil {
+				return fmt.Errorf("git checkout failed: %v: %s", err, strings.TrimSpace(string(out)))
+			}
+		}
+		out, err = pkgIntHelper.RunCommandInDir(srcDir, "git", "rev-parse", "HEAD")
+		if err != nil {
+			return fmt.Errorf("git rev-parse failed: %v: %s", err, strings.TrimSpace(string(out)))
+		}
+		if head := strings.TrimSpace(string(out)); head != commit {
+			return fmt.Errorf("commit mismatch of %s: expected %s, got %s", location, commit, head)
+		}
+	}
+	return os.RemoveAll(filepath.Join(srcDir, ".git"))
+}
+
+// Copies the plugin directory of an OCI image, the image is pulled with the
+// container engine by its digest and must hold the plugin in
+// PluginImageDir.
+func fetchPluginImage(ce ContainerEngine, image string, srcDir string, digest string) error {
+	image, err := pinImageDigest(image, digest)
+	if err != nil {
+		return err
+	}
+
+	_, err = pkgIntHelper.RunCommandOutput(ce.GetExecName(), ce.GetPullArgs(image)...)
+	if err != nil {
+		return fmt.Errorf("failed to pull %s: %v", image, pkgIntHelper.CommandError(err))
+	}
+
+	// The container is only created to copy the plugin out of the image
+	containerName := fmt.Sprintf("ow-plugin-install-%s", uuid.New().String()[:6])
+	_, err = pkgIntHelper.RunCommandOutput(ce.GetExecName(), ce.GetCreateArgs(containerName, image, "true")...)
+	if err != nil {
+		return fmt.Errorf("failed to create a container of %s: %v", image, pkgIntHelper.CommandError(err))
+	}
+	defer pkgIntHelper.RunCommandOutput(ce.GetExecName(), ce.GetRmArgs(true, containerName)...)
+
+	_, err = pkgIntHelper.RunCommandOutput(
+		ce.GetExecName(),
+		ce.GetCpArgs(fmt.Sprintf("%s:%s", containerName, PluginImageDir), srcDir)...,
+	)
+	if err != nil {
+		return fmt.Errorf("failed to copy %s out of %s: %v", PluginImageDir, image, pkgIntHelper.CommandError(err))
+	}
+	return checkRegularFiles(srcDir)
+}
+
+// Checks that a directory only holds regular files and directories, as the
+// tarball extraction does. Links could point outside of the plugin directory.
+func checkRegularFiles(dir string) error {
+	return filepath.Walk(dir, func(path string, info os.FileInfo, err error) error {
+		if err != nil {
+			return err
+		}
+		if !info.IsDir() && !info.Mode().IsRegular() {
+			rel, _ := filepath.Rel(dir, path)
+			return fmt.Errorf("image entry %s is not a file or directory", rel)
+		}
+		return nil
+	})
+}
+
+// Gets the image reference pinned to a digest (repository@sha256:<digest>),
+// the tag is dropped. A reference that is already pinned must match the
+// digest.
+func pinImageDigest(image string, digest string) (string, error) {
+	if repo, pinned, ok := strings.Cut(image, "@"); ok {
+		if len(digest) > 0 && normalizeSha256(pinned) != normalizeSha256(digest) {
+			return "", fmt.Errorf("image %s is not pinned to sha256:%s", image, normalizeSha256(digest))
+		}
+		return fmt.Sprintf("%s@%s", repo, pinned), nil
+	}
+	if len(digest) == 0 {
+		return image, nil
+	}
+
+	repo := image
+	if idx := strings.LastIndex(image, ":"); idx > strings.LastIndex(image, "/") {
+		repo = image[:idx]
+	}
+	return fmt.Sprintf("%s@sha256:%s", repo, normalizeSha256(digest)), nil
+}
+
+// Copies the regular files and directories of a directory.
+func copyDir(src string, dst string) error {
+	return filepath.Walk(src, func(path string, info os.FileInfo, err error) error {
+		if err != nil {
+			return err
+		}
+		rel, err := filepath.Rel(src, path)
+		if err != nil {
+			return err
+		}
+		target := filepath.Join(dst, rel)
+
+		switch {
+		case info.IsDir():
+			if info.Name() == ".git" {
+				return filepath.SkipDir
+			}
+			return os.MkdirAll(target, 0755)
+		case info.Mode().IsRegular():
+			file, err := os.Open(path)
+			if err != nil {
+				return err
+			}
+			defer file.Close()
+			return writeFile(target, file, info.Mode().Perm())
+		default:
+			logger.Warnf("Skipping %s, it is not a file or directory", path)
+			return nil
+		}
+	})
+}
+
+func writeFile(path string, reader io.Reader, mode os.FileMode) error {
+	err := os.MkdirAll(filepath.Dir(path), 0755)
+	if err != nil {
+		return err
+	}
+	file, err := os.OpenFile(path, os.O_CREATE|os.O_WRONLY|os.O_TRUNC, mode)
+	if err != nil {
+		return err
+	}
+	defer file.Close()
+	_, err = io.Copy(file, reader)
+	return err
+}
@@ -0,0 +1,369 @@
+/*
+Copyright © 2023 Jose Cueto
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package internal
+
+import (
+	"archive/tar"
+	"bytes"
+	"compress/gzip"
+	"crypto/sha256"
+	"encoding/hex"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+const testDigest = "0123456789abcdef0123456789abcdef0123456789abcdef0123456789abcdef"
+
+const testManifest = `name: hello
+version: 0.1.0
+commands: [hello]
+hooks: [shellStart]
+`
+
+type tarEntry struct {
+	name     string
+	typeflag byte
+	content  string
+	mode     int64
+	linkname string
+}
+
+func writeTestTarball(t *testing.T, path string, entries []tarEntry) []byte {
+	t.Helper()
+	var buf bytes.Buffer
+	gzipWriter := gzip.NewWriter(&buf)
+	tarWriter := tar.NewWriter(gzipWriter)
+	for _, entry := range entries {
+		header := &tar.Header{
+			Name:     entry.name,
+			Typeflag: entry.typeflag,
+			Mode:     entry.mode,
+			Size:     int64(len(entry.content)),
+			Linkname: entry.linkname,
+		}
+		if entry.typeflag != tar.TypeReg {
+			header.Size = 0
+		}
+		if err := tarWriter.WriteHeader(header); err != nil {
+			t.Fatal(err)
+		}
+		if entry.typeflag == tar.TypeReg {
+			if _, err := tarWriter.Write([]byte(entry.content)); err != nil {
+				t.Fatal(err)
+			}
+		}
+	}
+	if err := tarWriter.Close(); err != nil {
+		t.Fatal(err)
+	}
+	if err := gzipWriter.Close(); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(path, buf.Bytes(), 0600); err != nil {
+		t.Fatal(err)
+	}
+	return buf.Bytes()
+}
+
+func helloPluginEntries() []tarEntry {
+	return []tarEntry{
+		{name: "hello/", typeflag: tar.TypeDir, mode: 0755},
+		{name: "hello/plugin.yaml", typeflag: tar.TypeReg, content: testManifest, mode: 0644},
+		{name: "hello/hello", typeflag: tar.TypeReg, content: "#!/bin/sh\n", mode: 0755},
+	}
+}
+
+func TestExtractTarball(t *testing.T) {
+	tests := []struct {
+		name    string
+		entries []tarEntry
+		wantErr string
+	}{
+		{
+			name:    "plugin",
+			entries: helloPluginEntries(),
+		},
+		{
+			name: "traversal",
+			entries: []tarEntry{
+				{name: "../evil", typeflag: tar.TypeReg, content: "evil", mode: 0644},
+			},
+			wantErr: "outside of the plugin directory",
+		},
+		{
+			name: "nested traversal",
+			entries: []tarEntry{
+				{name: "hello/../../evil", typeflag: tar.TypeReg, content: "evil", mode: 0644},
+			},
+			wantErr: "outside of the plugin directory",
+		},
+		{
+			name: "symlink",
+			entries: []tarEntry{
+				{name: "hello/passwd", typeflag: tar.TypeSymlink, linkname: "/etc/passwd", mode: 0777},
+			},
+			wantErr: "is not a file or directory",
+		},
+		{
+			name: "hard link",
+			entries: []tarEntry{
+				{name: "hello/passwd", typeflag: tar.TypeLink, linkname: "/etc/passwd", mode: 0644},
+			},
+			wantErr: "is not a file or directory",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			tmpDir := t.TempDir()
+			tarball := filepath.Join(tmpDir, "plugin.tar.gz")
+			writeTestTarball(t, tarball, tt.entries)
+			dst := filepath.Join(tmpDir, "src")
+
+			err := extractTarball(tarball, dst)
+			if len(tt.wantErr) > 0 {
+				if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+					t.Fatalf("expected error containing %q, got %v", tt.wantErr, err)
+				}
+				if _, err := os.Stat(filepath.Join(tmpDir, "evil")); err == nil {
+					t.Fatal("entry was written outside of the plugin directory")
+				}
+				return
+			}
+			if err != nil {
+				t.Fatal(err)
+			}
+			info, err := os.Stat(filepath.Join(dst, "hello", "hello"))
+			if err != nil {
+				t.Fatal(err)
+			}
+			if info.Mode().Perm() != 0755 {
+				t.Fatalf("expected mode 0755, got %#o", info.Mode().Perm())
+			}
+		})
+	}
+}
+
+func TestVerifySha256(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "plugin.tar.gz")
+	content := []byte("plugin")
+	if err := os.WriteFile(path, content, 0600); err != nil {
+		t.Fatal(err)
+	}
+	hash := sha256.Sum256(content)
+	checksum := hex.EncodeToString(hash[:])
+
+	for _, valid := range []string{checksum, "sha256:" + checksum, strings.ToUpper(checksum)} {
+		if err := verifySha256(path, valid); err != nil {
+			t.Errorf("checksum %s: %v", valid, err)
+		}
+	}
+	if err := verifySha256(path, testDigest); err == nil || !strings.Contains(err.Error(), "checksum mismatch") {
+		t.Errorf("expected a checksum mismatch, got %v", err)
+	}
+}
+
+func TestCheckPluginVerification(t *testing.T) {
+	tests := []struct {
+		name     string
+		kind     string
+		location string
+		opts     PluginInstallOptions
+		wantErr  string
+	}{
+		{name: "local path", kind: PluginSourcePath, location: "./hello"},
+		{name: "local tarball", kind: PluginSourceTarball, location: "hello.tgz"},
+		{name: "remote tarball", kind: PluginSourceTarball, location: "https://example.com/hello.tgz", wantErr: "requires --sha256"},
+		{name: "remote tarball with checksum", kind: PluginSourceTarball, location: "https://example.com/hello.tgz", opts: PluginInstallOptions{Sha256: testDigest}},
+		{name: "remote tarball insecure", kind: PluginSourceTarball, location: "http://example.com/hello.tgz", opts: PluginInstallOptions{Insecure: true}},
+		{name: "invalid checksum", kind: PluginSourceTarball, location: "https://example.com/hello.tgz", opts: PluginInstallOptions{Sha256: "abc"}, wantErr: "is not a SHA-256 checksum"},
+		{name: "image tag", kind: PluginSourceOci, location: "registry.local/hello:1.0", wantErr: "requires --sha256"},
+		{name: "image digest", kind: PluginSourceOci, location: "registry.local/hello@sha256:" + testDigest},
+		{name: "image with checksum", kind: PluginSourceOci, location: "registry.local/hello:1.0", opts: PluginInstallOptions{Sha256: testDigest}},
+		{name: "git", kind: PluginSourceGit, location: "https://example.com/hello.git#v1", wantErr: "requires --commit"},
+		{name: "git with commit", kind: PluginSourceGit, location: "https://example.com/hello.git", opts: PluginInstallOptions{Commit: strings.Repeat("a", 40)}},
+		{name: "git with checksum", kind: PluginSourceGit, location: "https://example.com/hello.git", opts: PluginInstallOptions{Sha256: testDigest}, wantErr: "only verified for tarballs and OCI images"},
+		{name: "short commit", kind: PluginSourceGit, location: "https://example.com/hello.git", opts: PluginInstallOptions{Commit: "abc1234"}, wantErr: "is not a full git commit ID"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := checkPluginVerification(tt.kind, tt.location, tt.opts)
+			if len(tt.wantErr) == 0 {
+				if err != nil {
+					t.Fatal(err)
+				}
+				return
+			}
+			if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+				t.Fatalf("expected error containing %q, got %v", tt.wantErr, err)
+			}
+		})
+	}
+}
+
+// stubEngine runs a container engine stand-in script that serves a single
+// pinned image, like a local registry holding the plugin image.
+type stubEngine struct {
+	*podman
+	execName string
+}
+
+func (s *stubEngine) GetExecName() string {
+	return s.execName
+}
+
+const stubEngineScript = `#!/bin/sh
+echo "$@" >> "$STUB_LOG"
+case "$1" in
+pull)
+	[ "$3" = "$STUB_IMAGE" ] || { echo "manifest unknown: $3" >&2; exit 1; } ;;
+create)
+	[ "$4" = "$STUB_IMAGE" ] || exit 1 ;;
+cp)
+	mkdir -p "$3" && cp -R "$STUB_PLUGIN_DIR/." "$3" ;;
+esac
+`
+
+func newStubEngine(t *testing.T, image string) (*stubEngine, string) {
+	t.Helper()
+	tmpDir := t.TempDir()
+	pluginDir := filepath.Join(tmpDir, "image", "plugin")
+	if err := os.MkdirAll(pluginDir, 0755); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(filepath.Join(pluginDir, PluginManifestFile), []byte(testManifest), 0644); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(filepath.Join(pluginDir, "hello"), []byte("#!/bin/sh\n"), 0755); err != nil {
+		t.Fatal(err)
+	}
+
+	script := filepath.Join(tmpDir, "engine")
+	if err := os.WriteFile(script, []byte(stubEngineScript), 0755); err != nil {
+		t.Fatal(err)
+	}
+	logPath := filepath.Join(tmpDir, "engine.log")
+	t.Setenv("STUB_LOG", logPath)
+	t.Setenv("STUB_IMAGE", image)
+	t.Setenv("STUB_PLUGIN_DIR", pluginDir)
+	return &stubEngine{podman: NewPodman(), execName: script}, logPath
+}
+
+func TestFetchPluginImage(t *testing.T) {
+	pinned := "registry.local:5000/plugins/hello@sha256:" + testDigest
+	ce, logPath := newStubEngine(t, pinned)
+	srcDir := filepath.Join(t.TempDir(), "src")
+
+	err := fetchPluginImage(ce, "registry.local:5000/plugins/hello:1.0", srcDir, "sha256:"+testDigest)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := LoadPluginManifest(srcDir); err != nil {
+		t.Fatal(err)
+	}
+
+	log, err := os.ReadFile(logPath)
+	if err != nil {
+		t.Fatal(err)
+	}
+	calls := strings.Split(strings.TrimSpace(string(log)), "\n")
+	if len(calls) != 4 {
+		t.Fatalf("expected pull, create, cp and rm, got %q", calls)
+	}
+	for idx, prefix := range []string{"pull --quiet " + pinned, "create --name ow-plugin-install-", "cp ow-plugin-install-", "rm -f ow-plugin-install-"} {
+		if !strings.HasPrefix(calls[idx], prefix) {
+			t.Errorf("call %d: expected prefix %q, got %q", idx, prefix, calls[idx])
+		}
+	}
+}
+
+func TestFetchPluginImageLinks(t *testing.T) {
+	pinned := "registry.local/hello@sha256:" + testDigest
+	for _, name := range []string{"passwd", "nested/passwd"} {
+		t.Run(name, func(t *testing.T) {
+			ce, _ := newStubEngine(t, pinned)
+			link := filepath.Join(os.Getenv("STUB_PLUGIN_DIR"), name)
+			if err := os.MkdirAll(filepath.Dir(link), 0755); err != nil {
+				t.Fatal(err)
+			}
+			if err := os.Symlink("/etc/passwd", link); err != nil {
+				t.Fatal(err)
+			}
+
+			err := fetchPluginImage(ce, pinned, filepath.Join(t.TempDir(), "src"), "")
+			if err == nil || !strings.Contains(err.Error(), "is not a file or directory") {
+				t.Fatalf("expected the symlink %s to be rejected, got %v", name, err)
+			}
+		})
+	}
+}
+
+func TestFetchPluginImageDigestMismatch(t *testing.T) {
+	ce, logPath := newStubEngine(t, "registry.local/hello@sha256:"+testDigest)
+	srcDir := filepath.Join(t.TempDir(), "src")
+
+	// A tag is pulled by the given digest, which the registry does not have
+	otherDigest := strings.Repeat("f", 64)
+	err := fetchPluginImage(ce, "registry.local/hello:1.0", srcDir, otherDigest)
+	if err == nil || !strings.Contains(err.Error(), "manifest unknown") {
+		t.Fatalf("expected a pull error, got %v", err)
+	}
+
+	// A pinned reference must match the digest before anything is pulled
+	os.Remove(logPath)
+	err = fetchPluginImage(ce, "registry.local/hello@sha256:"+testDigest, srcDir, otherDigest)
+	if err == nil || !strings.Contains(err.Error(), "is not pinned to") {
+		t.Fatalf("expected a digest mismatch, got %v", err)
+	}
+	if _, err := os.Stat(logPath); err == nil {
+		t.Fatal("the engine was run for a mismatched reference")
+	}
+}
+
+func TestInstallPluginRemoteTarball(t *testing.T) {
+	t.Setenv("HOME", t.TempDir())
+	content := writeTestTarball(t, filepath.Join(t.TempDir(), "hello.tar.gz"), helloPluginEntries())
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Write(content)
+	}))
+	defer server.Close()
+	source := server.URL + "/hello.tar.gz"
+	hash := sha256.Sum256(content)
+
+	_, err := InstallPlugin(source, PluginInstallOptions{})
+	if err == nil || !strings.Contains(err.Error(), "requires --sha256") {
+		t.Fatalf("expected a required checksum error, got %v", err)
+	}
+
+	_, err = InstallPlugin(source, PluginInstallOptions{Sha256: testDigest})
+	if err == nil || !strings.Contains(err.Error(), "checksum mismatch") {
+		t.Fatalf("expected a checksum mismatch, got %v", err)
+	}
+
+	manifest, err := InstallPlugin(source, PluginInstallOptions{Sha256: hex.EncodeToString(hash[:])})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if installed, err := GetInstalledPlugin("hello"); err != nil || installed.Dir != manifest.Dir {
+		t.Fatalf("expected plugin hello to be installed in %s, got %v", manifest.Dir, err)
+	}
+}
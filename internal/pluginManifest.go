@@ -0,0 +1,310 @@
+/*
+Copyright © 2023 Jose Cueto
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package internal
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strings"
+
+	"gopkg.in/yaml.v3"
+)
+
+// PluginManifestFile is the manifest file name in a plugin directory.
+const PluginManifestFile = "plugin.yaml"
+
+var pluginNameRegexp = regexp.MustCompile(`^[A-Za-z0-9][-_.A-Za-z0-9]*$`)
+
+// PluginManifest describes an installed plugin (plugin.yaml).
+type PluginManifest struct {
+	Name        string `json:"name" yaml:"name"`
+	Version     string `json:"version" yaml:"version"`
+	Description string `json:"description,omitempty" yaml:"description,omitempty"`
+	// Executable path relative to the plugin directory, defaults to the
+	// plugin name.
+	Executable string `json:"executable,omitempty" yaml:"executable,omitempty"`
+	// CLI commands of the executable, the first one is the default
+	// execCommand.
+	Commands []string `json:"commands" yaml:"commands"`
+	// Hook events the plugin supports, the first one is the default runOn.
+	Hooks []string `json:"hooks,omitempty" yaml:"hooks,omitempty"`
+	// Number of ports the plugin requires (the minimum allocatePorts).
+	Ports int `json:"ports,omitempty" yaml:"ports,omitempty"`
+	// Host environment variables the plugin requires.
+	Env []string `json:"env,omitempty" yaml:"env,omitempty"`
+	// JSON Schema (subset) of the plugin's config.
+	ConfigSchema map[string]interface{} `json:"configSchema,omitempty" yaml:"configSchema,omitempty"`
+	// Directory the plugin is installed in.
+	Dir string `json:"dir" yaml:"dir"`
+}
+
+// Gets the directory the plugins are installed in (~/.ocm-workspace/plugins).
+func GetPluginsDir() (string, error) {
+	stateDir, err := GetStateDir()
+	if err != nil {
+		return "", err
+	}
+	return filepath.Join(stateDir, "plugins"), nil
+}
+
+// Loads and checks the manifest of the plugin in a directory.
+func LoadPluginManifest(dir string) (*PluginManifest, error) {
+	manifestPath := filepath.Join(dir, PluginManifestFile)
+	content, err := os.ReadFile(manifestPath)
+	if err != nil {
+		return nil, err
+	}
+
+	manifest := &PluginManifest{}
+	err = yaml.Unmarshal(content, manifest)
+	if err != nil {
+		return nil, fmt.Errorf("failed to unmarshal %s: %v", manifestPath, err)
+	}
+	manifest.Dir = dir
+	if len(manifest.Executable) == 0 {
+		manifest.Executable = manifest.Name
+	}
+
+	err = manifest.check()
+	if err != nil {
+		return nil, fmt.Errorf("invalid plugin manifest %s: %v", manifestPath, err)
+	}
+	return manifest, nil
+}
+
+func (m *PluginManifest) check() error {
+	if !pluginNameRegexp.MatchString(m.Name) {
+		return fmt.Errorf("name %q must start with an alphanumeric character and only contain alphanumeric characters, -, _ or .", m.Name)
+	}
+	if len(m.Version) == 0 {
+		return errors.New("version is required")
+	}
+	if len(m.Commands) == 0 {
+		return errors.New("commands requires at least one command")
+	}
+	for _, hook := range m.Hooks {
+		if !contains(PluginRunOnValues, hook) {
+			return fmt.Errorf("hook %q is not one of %s", hook, strings.Join(PluginRunOnValues, ", "))
+		}
+	}
+	if m.Ports < 0 {
+		return errors.New("ports must not be negative")
+	}
+	if filepath.IsAbs(m.Executable) || strings.HasPrefix(filepath.Clean(m.Executable), "..") {
+		return fmt.Errorf("executable %s must be relative to the plugin directory", m.Executable)
+	}
+	if _, err := os.Stat(m.GetExecPath()); err != nil {
+		return fmt.Errorf("executable %s does not exist", m.GetExecPath())
+	}
+	return nil
+}
+
+// Gets the path of the plugin's executable.
+func (m *PluginManifest) GetExecPath() string {
+	return filepath.Join(m.Dir, m.Executable)
+}
+
+// Lists the plugins installed in the plugins directory by name. Plugins with
+// an invalid manifest are returned as errors.
+func ListInstalledPlugins() ([]PluginManifest, []error) {
+	pluginsDir, err := GetPluginsDir()
+	if err != nil {
+		return nil, []error{err}
+	}
+
+	entries, err := os.ReadDir(pluginsDir)
+	if errors.Is(err, os.ErrNotExist) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, []error{err}
+	}
+
+	manifests := []PluginManifest{}
+	errs := []error{}
+	for _, entry := range entries {
+		// Hidden directories are plugins being installed
+		if !entry.IsDir() || strings.HasPrefix(entry.Name(), ".") {
+			continue
+		}
+		manifest, err := LoadPluginManifest(filepath.Join(pluginsDir, entry.Name()))
+		if err != nil {
+			errs = append(errs, err)
+			continue
+		}
+		manifests = append(manifests, *manifest)
+	}
+	sort.Slice(manifests, func(i, j int) bool {
+		return manifests[i].Name < manifests[j].Name
+	})
+	return manifests, errs
+}
+
+// Gets an installed plugin by name.
+func GetInstalledPlugin(name string) (*PluginManifest, error) {
+	pluginsDir, err := GetPluginsDir()
+	if err != nil {
+		return nil, err
+	}
+
+	dir := filepath.Join(pluginsDir, name)
+	if _, err := os.Stat(filepath.Join(dir, PluginManifestFile)); err != nil {
+		return nil, fmt.Errorf("plugin %s is not installed in %s", name, pluginsDir)
+	}
+	manifest, err := LoadPluginManifest(dir)
+	if err != nil {
+		return nil, err
+	}
+	if manifest.Name != name {
+		return nil, fmt.Errorf("plugin %s in %s is named %s", name, dir, manifest.Name)
+	}
+	return manifest, nil
+}
+
+// Fills in the plugins without an execPath (including the plugins of the
+// clusters entries) from the installed plugins of the same name. Plugins
+// that are not installed are left to the validation.
+func (c *OcmWorkspaceConfig) ResolvePlugins() {
+	resolvePlugins(c.Plugins)
+	for _, cluster := range c.Clusters {
+		resolvePlugins(cluster.Plugins)
+	}
+}
+
+func resolvePlugins(plugins []Plugin) {
+	for idx := range plugins {
+		plug := &plugins[idx]
+		if len(plug.ExecPath) > 0 {
+			continue
+		}
+		manifest, err := GetInstalledPlugin(plug.Name)
+		if err != nil {
+			continue
+		}
+
+		plug.manifest = manifest
+		plug.ExecPath = manifest.GetExecPath()
+		if len(plug.ExecCommand) == 0 {
+			plug.ExecCommand = manifest.Commands[0]
+		}
+		if len(plug.RunOn) == 0 && len(manifest.Hooks) > 0 {
+			plug.RunOn = manifest.Hooks[0]
+		}
+		if plug.AllocatePorts == 0 {
+			plug.AllocatePorts = manifest.Ports
+		}
+		for _, name := range manifest.Env {
+			if !contains(plug.Env, name) {
+				plug.Env = append(plug.Env, name)
+			}
+		}
+	}
+}
+
+// Validates a plugin config entry against the manifest of the installed
+// plugin it references.
+func validatePluginManifest(v *configValidator, path string, plug Plugin) {
+	manifest := plug.manifest
+	if !contains(manifest.Commands, plug.ExecCommand) {
+		v.addf(path+".execCommand", "%q is not a command of plugin %s (%s)", plug.ExecCommand, manifest.Name, strings.Join(manifest.Commands, ", "))
+	}
+	if len(manifest.Hooks) > 0 && !contains(manifest.Hooks, plug.RunOn) {
+		v.addf(path+".runOn", "plugin %s does not support %q (supported: %s)", manifest.Name, plug.RunOn, strings.Join(manifest.Hooks, ", "))
+	}
+	if plug.AllocatePorts < manifest.Ports {
+		v.addf(path+".allocatePorts", "plugin %s requires %d ports", manifest.Name, manifest.Ports)
+	}
+	if len(manifest.ConfigSchema) == 0 {
+		return
+	}
+
+	var pluginConfig interface{}
+	err := yaml.Unmarshal([]byte(plug.Config), &pluginConfig)
+	if err != nil {
+		v.addf(path+".config", "is not YAML: %v", err)
+		return
+	}
+	validateSchemaValue(v, path+".config", manifest.ConfigSchema, pluginConfig)
+}
+
+// Validates a value against a JSON Schema subset: type, enum, properties,
+// required and items.
+func validateSchemaValue(v *configValidator, path string, schema map[string]interface{}, value interface{}) {
+	if schemaType, ok := schema["type"].(string); ok && !isSchemaType(schemaType, value) {
+		v.addf(path, "must be of type %s", schemaType)
+		return
+	}
+
+	if enum, ok := schema["enum"].([]interface{}); ok {
+		found := false
+		for _, allowed := range enum {
+			if fmt.Sprint(allowed) == fmt.Sprint(value) {
+				found = true
+			}
+		}
+		if !found {
+			v.addf(path, "%v is not one of the allowed values %v", value, enum)
+		}
+	}
+
+	switch typed := value.(type) {
+	case map[string]interface{}:
+		if required, ok := schema["required"].([]interface{}); ok {
+			for _, name := range required {
+				if _, ok := typed[fmt.Sprint(name)]; !ok {
+					v.addf(joinSchemaPath(path, fmt.Sprint(name)), "is required")
+				}
+			}
+		}
+		properties, _ := schema["properties"].(map[string]interface{})
+		for _, name := range sortedKeys(typed) {
+			if property, ok := properties[name].(map[string]interface{}); ok {
+				validateSchemaValue(v, joinSchemaPath(path, name), property, typed[name])
+			}
+		}
+	case []interface{}:
+		if items, ok := schema["items"].(map[string]interface{}); ok {
+			for idx, item := range typed {
+				validateSchemaValue(v, fmt.Sprintf("%s[%d]", path, idx), items, item)
+			}
+		}
+	}
+}
+
+func isSchemaType(schemaType string, value interface{}) bool {
+	switch value.(type) {
+	case map[string]interface{}:
+		return schemaType == "object"
+	case []interface{}:
+		return schemaType == "array"
+	case string:
+		return schemaType == "string"
+	case int:
+		return schemaType == "integer" || schemaType == "number"
+	case float64:
+		return schemaType == "number"
+	case bool:
+		return schemaType == "boolean"
+	case nil:
+		return schemaType == "null"
+	}
+	return false
+}
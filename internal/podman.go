@@ -0,0 +1,41 @@
+/*
+Copyright © 2023 Jose Cueto
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+package internal
+
+type podman struct {
+	ceArgs
+}
+
+func NewPodman() *podman {
+	return &podman{}
+}
+
+func (p *podman) ToVolMapArgs() []string {
+	// Podman supports all of the volume map attributes (e.g. ro, z, U, O)
+	return p.toVolMapArgs(nil)
+}
+
+func (p *podman) GetRunArgs(containerName string, entryPoint string, image string, entryPointArgs ...string) []string {
+	return p.getRunArgs(p.ToVolMapArgs(), containerName, entryPoint, image, entryPointArgs...)
+}
+
+func (p *podman) GetRegistryAuthEnvVars(authFile string) [][]string {
+	return [][]string{{"REGISTRY_AUTH_FILE", authFile}}
+}
+
+func (p *podman) GetExecName() string {
+	return "podman"
+}
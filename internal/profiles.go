@@ -0,0 +1,67 @@
+/*
+Copyright © 2023 Jose Cueto
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package internal
+
+import (
+	"fmt"
+	"strings"
+)
+
+// ProfileExtendsKey names the profile that a profile inherits from. Profiles
+// without it inherit from the base config only.
+const ProfileExtendsKey = "extends"
+
+// Merges a profile into the merged config files. The profiles it extends are
+// merged first, maps are merged and other values (including lists) are
+// replaced.
+func (s *ConfigSources) ApplyProfile(name string) error {
+	chain := []map[string]interface{}{}
+	chainNames := []string{}
+	visited := []string{}
+	for current := name; len(current) > 0; {
+		for _, seen := range visited {
+			if seen == current {
+				return fmt.Errorf("profile %s extends itself (%s -> %s)", current, strings.Join(visited, " -> "), current)
+			}
+		}
+		visited = append(visited, current)
+		chainNames = append([]string{current}, chainNames...)
+
+		profile, ok := s.Get(fmt.Sprintf("profiles.%s", current)).(map[string]interface{})
+		if strings.Contains(current, ".") || !ok {
+			return fmt.Errorf("profile %s not found in the config file", current)
+		}
+		chain = append([]map[string]interface{}{profile}, chain...)
+
+		extends, ok := lookupKey(profile, ProfileExtendsKey).(string)
+		if lookupKey(profile, ProfileExtendsKey) != nil && !ok {
+			return fmt.Errorf("profiles.%s.%s must be a profile name", current, ProfileExtendsKey)
+		}
+		current = extends
+	}
+
+	for idx, profile := range chain {
+		settings := map[string]interface{}{}
+		for key, value := range profile {
+			if !strings.EqualFold(key, ProfileExtendsKey) {
+				settings[key] = value
+			}
+		}
+		s.Merge(settings, fmt.Sprintf("profile %s", chainNames[idx]))
+	}
+	return nil
+}
@@ -0,0 +1,136 @@
+/*
+Copyright © 2023 Jose Cueto
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package internal
+
+import (
+	"encoding/json"
+	"reflect"
+	"strings"
+
+	pkgIntToken "ocm-workspace/internal/token"
+)
+
+// Enum values of the config keys by YAML path, "[]" stands for any list item
+// and "*" for any map key.
+var schemaEnums = map[string][]string{
+	"containerEngine":                       supportedContainerEngines,
+	"plugins[].runOn":                       PluginRunOnValues,
+	"plugins[].restartPolicy":               PluginRestartPolicies,
+	"clusters.*.plugins[].runOn":            PluginRunOnValues,
+	"clusters.*.plugins[].restartPolicy":    PluginRestartPolicies,
+	"tokenSources.*.type":                   tokenSourceTypes,
+	"tokenSources.*.backend":                keyringBackends,
+	"ocmEnvironments.*.tokenSource.type":    tokenSourceTypes,
+	"ocmEnvironments.*.tokenSource.backend": keyringBackends,
+}
+
+var tokenSourceTypes = []string{
+	pkgIntToken.SourceFile,
+	pkgIntToken.SourceEnv,
+	pkgIntToken.SourceOcm,
+	pkgIntToken.SourceCommand,
+	pkgIntToken.SourceKeyring,
+}
+
+var keyringBackends = []string{pkgIntToken.BackendSecretService, pkgIntToken.BackendPass}
+
+// Generates the JSON Schema of the config file from the config struct.
+func GenerateJSONSchema() ([]byte, error) {
+	schema := schemaOf(reflect.TypeOf(OcmWorkspaceConfig{}), "")
+
+	// A profile holds any of the config keys
+	properties := schema["properties"].(map[string]interface{})
+	profileProperties := map[string]interface{}{
+		ProfileExtendsKey: map[string]interface{}{"type": "string"},
+	}
+	for name, property := range properties {
+		if name != "profiles" && name != ConfigIncludeKey {
+			profileProperties[name] = property
+		}
+	}
+	properties["profiles"] = map[string]interface{}{
+		"type": "object",
+		"additionalProperties": map[string]interface{}{
+			"type":       "object",
+			"properties": profileProperties,
+		},
+	}
+
+	schema["$schema"] = "https://json-schema.org/draft/2020-12/schema"
+	schema["title"] = "ocm-workspace config"
+	return json.MarshalIndent(schema, "", "  ")
+}
+
+func schemaOf(t reflect.Type, path string) map[string]interface{} {
+	for t.Kind() == reflect.Ptr {
+		t = t.Elem()
+	}
+
+	schema := map[string]interface{}{}
+	switch t.Kind() {
+	case reflect.Struct:
+		properties := map[string]interface{}{}
+		addSchemaProperties(t, path, properties)
+		schema["type"] = "object"
+		schema["properties"] = properties
+	case reflect.Slice:
+		schema["type"] = "array"
+		schema["items"] = schemaOf(t.Elem(), path+"[]")
+	case reflect.Map:
+		schema["type"] = "object"
+		schema["additionalProperties"] = schemaOf(t.Elem(), joinSchemaPath(path, "*"))
+	case reflect.Bool:
+		schema["type"] = "boolean"
+	case reflect.Int, reflect.Int32, reflect.Int64:
+		schema["type"] = "integer"
+	default:
+		schema["type"] = "string"
+	}
+
+	if enum, ok := schemaEnums[path]; ok {
+		schema["enum"] = enum
+	}
+	return schema
+}
+
+// Adds the struct fields by their mapstructure names, the fields of squashed
+// structs are added to the same properties.
+func addSchemaProperties(t reflect.Type, path string, properties map[string]interface{}) {
+	for idx := 0; idx < t.NumField(); idx++ {
+		field := t.Field(idx)
+		tag := field.Tag.Get("mapstructure")
+		name, opts, _ := strings.Cut(tag, ",")
+		if !field.IsExported() || name == "-" {
+			continue
+		}
+		if opts == "squash" {
+			addSchemaProperties(field.Type, path, properties)
+			continue
+		}
+		if len(name) == 0 {
+			name = field.Name
+		}
+		properties[name] = schemaOf(field.Type, joinSchemaPath(path, name))
+	}
+}
+
+func joinSchemaPath(path string, name string) string {
+	if len(path) == 0 {
+		return name
+	}
+	return path + "." + name
+}
@@ -0,0 +1,135 @@
+/*
+Copyright © 2023 Jose Cueto
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package internal
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"syscall"
+
+	"gopkg.in/yaml.v3"
+)
+
+// Path where the OCM token file is mounted in the workspace container.
+const ContainerOcmTokenPath = "/run/secrets/ocm-token"
+
+// Path where the effective config is mounted in the workspace container.
+const ContainerConfigPath = "/etc/ocm-workspace.yaml"
+
+// Gets the host directory holding the secrets and the config of a workspace
+// container. It is located in $XDG_RUNTIME_DIR (tmpfs) when available and is
+// only accessible by the host user.
+func GetWorkspaceSecretsDir(containerName string) (string, error) {
+	runtimeDir := os.Getenv("XDG_RUNTIME_DIR")
+	if len(runtimeDir) == 0 {
+		// The fallback path is predictable, another user must not own it
+		runtimeDir = filepath.Join(os.TempDir(), fmt.Sprintf("ocm-workspace-%d", os.Getuid()))
+		err := checkPrivateDir(runtimeDir)
+		if err != nil {
+			return "", err
+		}
+	}
+
+	secretsDir := filepath.Join(runtimeDir, "ocm-workspace", containerName)
+	err := os.MkdirAll(secretsDir, 0700)
+	if err != nil {
+		return "", err
+	}
+
+	// Enforce the permissions of a directory created by a previous run
+	err = os.Chmod(secretsDir, 0700)
+	if err != nil {
+		return "", err
+	}
+	return secretsDir, nil
+}
+
+// Creates a directory only accessible by the current user, or checks that
+// the existing one is. Symbolic links are not followed.
+func checkPrivateDir(path string) error {
+	err := os.Mkdir(path, 0700)
+	if err != nil && !os.IsExist(err) {
+		return err
+	}
+
+	info, err := os.Lstat(path)
+	if err != nil {
+		return err
+	}
+	if !info.IsDir() {
+		return fmt.Errorf("%s is not a directory", path)
+	}
+	stat, ok := info.Sys().(*syscall.Stat_t)
+	if !ok || int(stat.Uid) != os.Getuid() {
+		return fmt.Errorf("%s is not owned by the current user", path)
+	}
+	if info.Mode().Perm() != 0700 {
+		return fmt.Errorf("%s has mode %o, expected 700", path, info.Mode().Perm())
+	}
+	return nil
+}
+
+// Writes the OCM token of a workspace container to a file that is only
+// readable by the host user and returns its path.
+func WriteWorkspaceOcmToken(containerName string, ocmToken string) (string, error) {
+	secretsDir, err := GetWorkspaceSecretsDir(containerName)
+	if err != nil {
+		return "", err
+	}
+
+	tokenPath := filepath.Join(secretsDir, "ocm-token")
+	err = os.WriteFile(tokenPath, []byte(ocmToken), 0600)
+	if err != nil {
+		return "", err
+	}
+	return tokenPath, nil
+}
+
+// Writes the effective config of a workspace container and returns its path.
+// The clusters entries are already overlaid and left out.
+func WriteWorkspaceConfig(containerName string, conf *OcmWorkspaceConfig) (string, error) {
+	secretsDir, err := GetWorkspaceSecretsDir(containerName)
+	if err != nil {
+		return "", err
+	}
+
+	workspaceConfig := *conf
+	workspaceConfig.Clusters = nil
+	content, err := yaml.Marshal(&workspaceConfig)
+	if err != nil {
+		return "", err
+	}
+
+	// Readable by the container's host user, the directory protects it on
+	// the host
+	configPath := filepath.Join(secretsDir, "config.yaml")
+	err = os.WriteFile(configPath, content, 0644)
+	if err != nil {
+		return "", err
+	}
+	return configPath, nil
+}
+
+// Removes the secrets of a workspace container.
+func RemoveWorkspaceSecrets(containerName string) error {
+	secretsDir, err := GetWorkspaceSecretsDir(containerName)
+	if err != nil {
+		return err
+	}
+	return os.RemoveAll(secretsDir)
+}
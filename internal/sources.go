@@ -0,0 +1,271 @@
+/*
+Copyright © 2023 Jose Cueto
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package internal
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"gopkg.in/yaml.v3"
+)
+
+// ConfigIncludeKey lists the config files that a config file includes.
+const ConfigIncludeKey = "include"
+
+// ConfigSources merges the config files and the selected profile, keeping
+// the origin of each value. From the lowest to the highest precedence:
+//
+//  1. the files included by the config file (in the listed order, each after
+//     the files that it includes)
+//  2. the config file
+//  3. the drop-in files (*.yaml in lexical order)
+//  4. the selected profile and the profiles it extends
+type ConfigSources struct {
+	Settings map[string]interface{}
+	// Origins of the values by key path (e.g. plugins, proxy.httpProxy)
+	Origins map[string]string
+	Files   []string
+}
+
+// Gets the drop-in directory of a config file, e.g. ~/.ocm-workspace.d for
+// ~/.ocm-workspace.yaml.
+func GetConfigDropInDir(configFile string) string {
+	return strings.TrimSuffix(configFile, filepath.Ext(configFile)) + ".d"
+}
+
+// Loads a config file, with its includes and drop-in files unless
+// withIncludes is false.
+func LoadConfigSources(configFile string, withIncludes bool) (*ConfigSources, error) {
+	sources := &ConfigSources{
+		Settings: map[string]interface{}{},
+		Origins:  map[string]string{},
+	}
+
+	err := sources.mergeFile(configFile, []string{}, withIncludes)
+	if err != nil || !withIncludes {
+		return sources, err
+	}
+
+	dropIns, err := filepath.Glob(filepath.Join(GetConfigDropInDir(configFile), "*.yaml"))
+	if err != nil {
+		return nil, err
+	}
+	sort.Strings(dropIns)
+	for _, dropIn := range dropIns {
+		err = sources.mergeFile(dropIn, []string{}, true)
+		if err != nil {
+			return nil, err
+		}
+	}
+	return sources, nil
+}
+
+// Merges the files included by a config file and then the file itself.
+// Relative includes are relative to the including file.
+func (s *ConfigSources) mergeFile(path string, including []string, withIncludes bool) error {
+	for _, file := range including {
+		if file == path {
+			return fmt.Errorf("config file %s includes itself (%s -> %s)", path, strings.Join(including, " -> "), path)
+		}
+	}
+
+	content, err := os.ReadFile(path)
+	if err != nil {
+		return err
+	}
+	settings := map[string]interface{}{}
+	err = yaml.Unmarshal(content, &settings)
+	if err != nil {
+		return fmt.Errorf("failed to parse config file %s: %v", path, err)
+	}
+
+	includes, err := toStringList(lookupKey(settings, ConfigIncludeKey))
+	if err != nil {
+		return fmt.Errorf("%s: %s %v", path, ConfigIncludeKey, err)
+	}
+	if !withIncludes {
+		includes = nil
+	}
+	for _, include := range includes {
+		include, err = expandIncludePath(include)
+		if err != nil {
+			return fmt.Errorf("%s: %s %v", path, ConfigIncludeKey, err)
+		}
+		if !filepath.IsAbs(include) {
+			include = filepath.Join(filepath.Dir(path), include)
+		}
+		err = s.mergeFile(include, append(including, path), true)
+		if err != nil {
+			return err
+		}
+	}
+
+	deleteKey(settings, ConfigIncludeKey)
+	s.Merge(settings, path)
+	s.Files = append(s.Files, path)
+	return nil
+}
+
+// Expands the environment variables ($VAR or ${VAR}) and a leading ~ of an
+// include path.
+func expandIncludePath(include string) (string, error) {
+	include = os.ExpandEnv(include)
+	if include != "~" && !strings.HasPrefix(include, "~/") {
+		return include, nil
+	}
+
+	home, err := os.UserHomeDir()
+	if err != nil {
+		return "", err
+	}
+	return filepath.Join(home, strings.TrimPrefix(include, "~")), nil
+}
+
+// Merges settings from an origin. Maps are merged, other values (including
+// lists) are replaced.
+func (s *ConfigSources) Merge(settings map[string]interface{}, origin string) {
+	mergeSettings(s.Settings, settings, "", origin, s.Origins)
+}
+
+func mergeSettings(dst map[string]interface{}, src map[string]interface{}, path string, origin string, origins map[string]string) {
+	for key, value := range src {
+		dstKey := key
+		for existing := range dst {
+			if strings.EqualFold(existing, key) {
+				dstKey = existing
+			}
+		}
+		keyPath := dstKey
+		if len(path) > 0 {
+			keyPath = path + "." + dstKey
+		}
+
+		srcMap, isSrcMap := value.(map[string]interface{})
+		dstMap, isDstMap := dst[dstKey].(map[string]interface{})
+		if isSrcMap && isDstMap {
+			mergeSettings(dstMap, srcMap, keyPath, origin, origins)
+			continue
+		}
+
+		// The replaced value no longer has its origins
+		for originPath := range origins {
+			if strings.HasPrefix(originPath, keyPath+".") {
+				delete(origins, originPath)
+			}
+		}
+		if isSrcMap {
+			dstMap = map[string]interface{}{}
+			mergeSettings(dstMap, srcMap, keyPath, origin, origins)
+			dst[dstKey] = dstMap
+			continue
+		}
+		dst[dstKey] = value
+		origins[keyPath] = origin
+	}
+}
+
+// Gets a copy of the merged settings, e.g. for viper which changes the keys
+// of the settings merged into it to lower case.
+func (s *ConfigSources) GetSettings() map[string]interface{} {
+	return copySettings(s.Settings)
+}
+
+func copySettings(settings map[string]interface{}) map[string]interface{} {
+	settingsCopy := map[string]interface{}{}
+	for key, value := range settings {
+		settingsCopy[key] = copySetting(value)
+	}
+	return settingsCopy
+}
+
+func copySetting(value interface{}) interface{} {
+	switch values := value.(type) {
+	case map[string]interface{}:
+		return copySettings(values)
+	case []interface{}:
+		valuesCopy := []interface{}{}
+		for _, item := range values {
+			valuesCopy = append(valuesCopy, copySetting(item))
+		}
+		return valuesCopy
+	default:
+		return value
+	}
+}
+
+// Gets the origins of the settings sorted by key path.
+func (s *ConfigSources) GetOrigins() [][]string {
+	origins := [][]string{}
+	for _, path := range sortedKeys(s.Origins) {
+		origins = append(origins, []string{path, s.Origins[path]})
+	}
+	return origins
+}
+
+// Gets a value by its key path, case insensitive like viper.
+func (s *ConfigSources) Get(path string) interface{} {
+	var value interface{} = s.Settings
+	for _, key := range strings.Split(path, ".") {
+		settings, ok := value.(map[string]interface{})
+		if !ok {
+			return nil
+		}
+		value = lookupKey(settings, key)
+	}
+	return value
+}
+
+func lookupKey(settings map[string]interface{}, key string) interface{} {
+	for existing, value := range settings {
+		if strings.EqualFold(existing, key) {
+			return value
+		}
+	}
+	return nil
+}
+
+func deleteKey(settings map[string]interface{}, key string) {
+	for existing := range settings {
+		if strings.EqualFold(existing, key) {
+			delete(settings, existing)
+		}
+	}
+}
+
+func toStringList(value interface{}) ([]string, error) {
+	switch values := value.(type) {
+	case nil:
+		return nil, nil
+	case string:
+		return []string{values}, nil
+	case []interface{}:
+		list := []string{}
+		for _, item := range values {
+			str, ok := item.(string)
+			if !ok {
+				return nil, fmt.Errorf("must be a list of file paths")
+			}
+			list = append(list, str)
+		}
+		return list, nil
+	default:
+		return nil, fmt.Errorf("must be a list of file paths")
+	}
+}
@@ -0,0 +1,110 @@
+/*
+Copyright © 2023 Jose Cueto
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package internal
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"syscall"
+)
+
+// Gets the directory where the workspace keeps its host side state (e.g. port
+// leases), creating it if it does not exist.
+func GetStateDir() (string, error) {
+	home, err := os.UserHomeDir()
+	if err != nil {
+		return "", err
+	}
+
+	stateDir := filepath.Join(home, ".ocm-workspace")
+	err = os.MkdirAll(stateDir, 0700)
+	if err != nil {
+		return "", err
+	}
+	return stateDir, nil
+}
+
+// Gets the path of the PID file of a background process.
+func getPidFilePath(name string) (string, error) {
+	stateDir, err := GetStateDir()
+	if err != nil {
+		return "", err
+	}
+	return filepath.Join(stateDir, fmt.Sprintf("%s.pid", name)), nil
+}
+
+// Records the PID of the current process as the background process name.
+func WritePidFile(name string) error {
+	pidPath, err := getPidFilePath(name)
+	if err != nil {
+		return err
+	}
+	return os.WriteFile(pidPath, []byte(strconv.Itoa(os.Getpid())), 0600)
+}
+
+// Removes the PID file of the background process name if it records the
+// current process.
+func RemovePidFile(name string) error {
+	pidPath, err := getPidFilePath(name)
+	if err != nil {
+		return err
+	}
+	content, err := os.ReadFile(pidPath)
+	if err != nil || strings.TrimSpace(string(content)) != strconv.Itoa(os.Getpid()) {
+		return nil
+	}
+	return os.Remove(pidPath)
+}
+
+// Terminates the background process name and removes its PID file. The
+// process is only signaled if its command line contains all of cmdlineArgs,
+// the recorded PID may have been reused by another process.
+func TerminatePidFile(name string, cmdlineArgs ...string) error {
+	pidPath, err := getPidFilePath(name)
+	if err != nil {
+		return err
+	}
+	content, err := os.ReadFile(pidPath)
+	if os.IsNotExist(err) {
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+	defer os.Remove(pidPath)
+
+	pid, err := strconv.Atoi(strings.TrimSpace(string(content)))
+	if err != nil {
+		return fmt.Errorf("invalid PID file %s: %v", pidPath, err)
+	}
+
+	cmdline, err := os.ReadFile(fmt.Sprintf("/proc/%d/cmdline", pid))
+	if err != nil {
+		// The process is gone
+		return nil
+	}
+	args := strings.Split(string(cmdline), "\x00")
+	for _, arg := range cmdlineArgs {
+		if !contains(args, arg) {
+			return nil
+		}
+	}
+	return syscall.Kill(pid, syscall.SIGTERM)
+}
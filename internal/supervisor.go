@@ -0,0 +1,309 @@
+/*
+Copyright © 2023 Jose Cueto
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package internal
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"sort"
+	"sync"
+	"syscall"
+	"time"
+
+	logger "github.com/sirupsen/logrus"
+)
+
+// Restart policies of the plugins run in the background (plugins.restartPolicy).
+const (
+	RestartNever     = "never"
+	RestartOnFailure = "onFailure"
+	RestartAlways    = "always"
+)
+
+var PluginRestartPolicies = []string{RestartNever, RestartOnFailure, RestartAlways}
+
+// Plugin process states.
+const (
+	PluginStarting = "starting"
+	PluginRunning  = "running"
+	PluginExited   = "exited"
+	PluginFailed   = "failed"
+	PluginBackoff  = "backoff"
+	PluginStopped  = "stopped"
+)
+
+// Directory in the workspace container holding the plugin state and logs.
+const ContainerPluginStateDir = "/run/ocm-workspace"
+
+const (
+	pluginInitialBackoff = time.Second
+	pluginMaxBackoff     = time.Minute
+	// A plugin that ran longer than this restarts without backoff.
+	pluginBackoffReset = 5 * time.Minute
+)
+
+// PluginStatus is the supervised state of a plugin process.
+type PluginStatus struct {
+	Name          string    `json:"name" yaml:"name"`
+	State         string    `json:"state" yaml:"state"`
+	Pid           int       `json:"pid,omitempty" yaml:"pid,omitempty"`
+	RestartPolicy string    `json:"restartPolicy" yaml:"restartPolicy"`
+	Restarts      int       `json:"restarts" yaml:"restarts"`
+	ExitCode      int       `json:"exitCode" yaml:"exitCode"`
+	StartedAt     time.Time `json:"startedAt,omitempty" yaml:"startedAt,omitempty"`
+	LogPath       string    `json:"logPath" yaml:"logPath"`
+}
+
+// PluginSupervisor runs plugins in the background, restarts them according to
+// their restart policy and stops them. The plugin states are saved to a file
+// for the workspace plugins status command.
+type PluginSupervisor struct {
+	stateDir  string
+	mutex     sync.Mutex
+	processes map[string]*pluginProcess
+	stopping  bool
+	stopCh    chan struct{}
+	wg        sync.WaitGroup
+}
+
+type pluginProcess struct {
+	status  PluginStatus
+	cmdName string
+	cmdArgs []string
+	envVars [][]string
+	cmd     *exec.Cmd
+}
+
+func NewPluginSupervisor(stateDir string) (*PluginSupervisor, error) {
+	err := os.MkdirAll(stateDir, 0755)
+	if err != nil {
+		return nil, err
+	}
+	return &PluginSupervisor{
+		stateDir:  stateDir,
+		processes: map[string]*pluginProcess{},
+		stopCh:    make(chan struct{}),
+	}, nil
+}
+
+// Starts a plugin command and supervises it until the supervisor stops.
+func (s *PluginSupervisor) Start(name string, restartPolicy string, cmdName string, cmdArgs []string, envVars [][]string) error {
+	if len(restartPolicy) == 0 {
+		restartPolicy = RestartNever
+	}
+
+	proc := &pluginProcess{
+		status: PluginStatus{
+			Name:          name,
+			State:         PluginStarting,
+			RestartPolicy: restartPolicy,
+			LogPath:       filepath.Join(s.stateDir, fmt.Sprintf("%s.log", name)),
+		},
+		cmdName: cmdName,
+		cmdArgs: cmdArgs,
+		envVars: envVars,
+	}
+
+	s.mutex.Lock()
+	s.processes[name] = proc
+	err := s.start(proc)
+	s.mutex.Unlock()
+	if err != nil {
+		return err
+	}
+
+	s.wg.Add(1)
+	go s.supervise(proc)
+	return nil
+}
+
+// Starts the process of a plugin, the caller holds the mutex.
+func (s *PluginSupervisor) start(proc *pluginProcess) error {
+	logFile, err := os.OpenFile(proc.status.LogPath, os.O_APPEND|os.O_CREATE|os.O_WRONLY, 0644)
+	if err != nil {
+		return err
+	}
+	defer logFile.Close()
+
+	cmd := exec.Command(proc.cmdName, proc.cmdArgs...)
+	for _, env := range proc.envVars {
+		cmd.Env = append(cmd.Env, fmt.Sprintf("%s=%s", env[0], env[1]))
+	}
+	cmd.Stdout = logFile
+	cmd.Stderr = logFile
+	// Signals are sent to the process group, e.g. sudo and the plugin
+	cmd.SysProcAttr = &syscall.SysProcAttr{Setpgid: true}
+
+	err = cmd.Start()
+	if err != nil {
+		proc.status.State = PluginFailed
+		s.save()
+		return err
+	}
+
+	proc.cmd = cmd
+	proc.status.State = PluginRunning
+	proc.status.Pid = cmd.Process.Pid
+	proc.status.StartedAt = time.Now()
+	s.save()
+	return nil
+}
+
+// Waits for the plugin process and restarts it with an exponential backoff
+// according to its restart policy.
+func (s *PluginSupervisor) supervise(proc *pluginProcess) {
+	defer s.wg.Done()
+	backoff := pluginInitialBackoff
+
+	for {
+		err := proc.cmd.Wait()
+
+		s.mutex.Lock()
+		proc.status.Pid = 0
+		proc.status.ExitCode = proc.cmd.ProcessState.ExitCode()
+		failed := err != nil
+		if failed {
+			proc.status.State = PluginFailed
+			logger.Errorf("Plugin %s failed: %v", proc.status.Name, err)
+		} else {
+			proc.status.State = PluginExited
+		}
+
+		restart := !s.stopping && (proc.status.RestartPolicy == RestartAlways ||
+			(proc.status.RestartPolicy == RestartOnFailure && failed))
+		if !restart {
+			if s.stopping {
+				proc.status.State = PluginStopped
+			}
+			s.save()
+			s.mutex.Unlock()
+			return
+		}
+
+		if time.Since(proc.status.StartedAt) > pluginBackoffReset {
+			backoff = pluginInitialBackoff
+		}
+		proc.status.State = PluginBackoff
+		s.save()
+		s.mutex.Unlock()
+
+		logger.Infof("Restarting plugin %s in %s", proc.status.Name, backoff)
+		select {
+		case <-time.After(backoff):
+		case <-s.stopCh:
+		}
+		backoff *= 2
+		if backoff > pluginMaxBackoff {
+			backoff = pluginMaxBackoff
+		}
+
+		s.mutex.Lock()
+		if s.stopping {
+			proc.status.State = PluginStopped
+			s.save()
+			s.mutex.Unlock()
+			return
+		}
+		proc.status.Restarts++
+		err = s.start(proc)
+		s.mutex.Unlock()
+		if err != nil {
+			logger.Errorf("Failed to restart plugin %s: %v", proc.status.Name, err)
+			return
+		}
+	}
+}
+
+// Stops the plugins with SIGTERM, and SIGKILL those still running after the
+// timeout.
+func (s *PluginSupervisor) Stop(timeout time.Duration) {
+	s.mutex.Lock()
+	if s.stopping {
+		s.mutex.Unlock()
+		return
+	}
+	s.stopping = true
+	close(s.stopCh)
+	for _, proc := range s.processes {
+		if proc.status.Pid > 0 {
+			logger.Debugf("Stopping plugin %s (pid %d)", proc.status.Name, proc.status.Pid)
+			syscall.Kill(-proc.status.Pid, syscall.SIGTERM)
+		}
+	}
+	s.mutex.Unlock()
+
+	done := make(chan struct{})
+	go func() {
+		s.wg.Wait()
+		close(done)
+	}()
+
+	select {
+	case <-done:
+	case <-time.After(timeout):
+		s.mutex.Lock()
+		for _, proc := range s.processes {
+			if proc.status.Pid > 0 {
+				logger.Warnf("Killing plugin %s (pid %d)", proc.status.Name, proc.status.Pid)
+				syscall.Kill(-proc.status.Pid, syscall.SIGKILL)
+			}
+		}
+		s.mutex.Unlock()
+		<-done
+	}
+}
+
+// Saves the plugin states, the caller holds the mutex.
+func (s *PluginSupervisor) save() {
+	statuses := []PluginStatus{}
+	for _, proc := range s.processes {
+		statuses = append(statuses, proc.status)
+	}
+	sort.Slice(statuses, func(i, j int) bool {
+		return statuses[i].Name < statuses[j].Name
+	})
+
+	content, err := json.MarshalIndent(statuses, "", "  ")
+	if err == nil {
+		err = os.WriteFile(getPluginStatePath(s.stateDir), content, 0644)
+	}
+	if err != nil {
+		logger.Warnf("Failed to save the plugin states: %v", err)
+	}
+}
+
+func getPluginStatePath(stateDir string) string {
+	return filepath.Join(stateDir, "plugins.json")
+}
+
+// Loads the plugin states saved by the supervisor.
+func LoadPluginStatus(stateDir string) ([]PluginStatus, error) {
+	statuses := []PluginStatus{}
+	content, err := os.ReadFile(getPluginStatePath(stateDir))
+	if os.IsNotExist(err) {
+		return statuses, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	err = json.Unmarshal(content, &statuses)
+	return statuses, err
+}
@@ -0,0 +1,59 @@
+/*
+Copyright © 2023 Jose Cueto
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package token
+
+import (
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+)
+
+// Decodes the (unverified) claims of a JWT.
+func decodeClaims(rawToken string, claims interface{}) error {
+	parts := strings.Split(strings.TrimSpace(rawToken), ".")
+	if len(parts) != 3 {
+		return errors.New("token is not a JWT")
+	}
+
+	payload, err := base64.RawURLEncoding.DecodeString(strings.TrimRight(parts[1], "="))
+	if err != nil {
+		return fmt.Errorf("failed to decode token payload: %v", err)
+	}
+
+	err = json.Unmarshal(payload, claims)
+	if err != nil {
+		return fmt.Errorf("failed to unmarshal token claims: %v", err)
+	}
+	return nil
+}
+
+// Gets the expiry time (exp claim) of a JWT.
+func GetExpiry(rawToken string) (time.Time, error) {
+	t, err := Parse(rawToken)
+	if err != nil {
+		return time.Time{}, err
+	}
+
+	expiresAt := t.ExpiresAt()
+	if expiresAt.IsZero() {
+		return time.Time{}, errors.New("token has no expiry")
+	}
+	return expiresAt, nil
+}
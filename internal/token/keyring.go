@@ -0,0 +1,135 @@
+/*
+Copyright © 2023 Jose Cueto
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package token
+
+import (
+	"fmt"
+	"strings"
+
+	pkgIntHelper "ocm-workspace/internal/helpers"
+)
+
+// Host keyring backends.
+const (
+	BackendSecretService = "secret-service"
+	BackendPass          = "pass"
+)
+
+// Name under which the tokens are stored in the host keyring.
+const keyringService = "ocm-workspace"
+
+// Store keeps OCM tokens per OCM environment in the host keyring.
+type Store interface {
+	Set(ocmEnvironment string, token string) error
+	Get(ocmEnvironment string) (string, error)
+	Remove(ocmEnvironment string) error
+	String() string
+}
+
+// Creates a host keyring store, defaults to the Secret Service backend.
+func NewStore(backend string) (Store, error) {
+	switch backend {
+	case BackendSecretService, "":
+		return &secretServiceStore{}, nil
+	case BackendPass:
+		return &passStore{}, nil
+	default:
+		return nil, fmt.Errorf(
+			"keyring backend %q is not supported (supported: %s, %s)",
+			backend,
+			BackendSecretService,
+			BackendPass,
+		)
+	}
+}
+
+// secretServiceStore uses the Secret Service D-Bus API (e.g. GNOME Keyring,
+// KWallet) through libsecret's secret-tool.
+type secretServiceStore struct{}
+
+func (s *secretServiceStore) attributes(ocmEnvironment string) []string {
+	return []string{"service", keyringService, "environment", ocmEnvironment}
+}
+
+func (s *secretServiceStore) Set(ocmEnvironment string, token string) error {
+	args := []string{"store", fmt.Sprintf("--label=OCM workspace token (%s)", ocmEnvironment)}
+	args = append(args, s.attributes(ocmEnvironment)...)
+	_, err := pkgIntHelper.RunCommandWithInput(token, "secret-tool", args...)
+	return pkgIntHelper.CommandError(err)
+}
+
+func (s *secretServiceStore) Get(ocmEnvironment string) (string, error) {
+	args := append([]string{"lookup"}, s.attributes(ocmEnvironment)...)
+	out, err := pkgIntHelper.RunCommandOutput("secret-tool", args...)
+	if err != nil {
+		return "", fmt.Errorf("no token of OCM environment %s found in the keyring: %v", ocmEnvironment, pkgIntHelper.CommandError(err))
+	}
+	return strings.TrimSpace(string(out)), nil
+}
+
+func (s *secretServiceStore) Remove(ocmEnvironment string) error {
+	args := append([]string{"clear"}, s.attributes(ocmEnvironment)...)
+	_, err := pkgIntHelper.RunCommandOutput("secret-tool", args...)
+	return pkgIntHelper.CommandError(err)
+}
+
+func (s *secretServiceStore) String() string {
+	return "Secret Service keyring"
+}
+
+// passStore uses the pass password store.
+type passStore struct{}
+
+func (s *passStore) entry(ocmEnvironment string) string {
+	return fmt.Sprintf("%s/%s", keyringService, ocmEnvironment)
+}
+
+func (s *passStore) Set(ocmEnvironment string, token string) error {
+	_, err := pkgIntHelper.RunCommandWithInput(token+"\n", "pass", "insert", "--multiline", "--force", s.entry(ocmEnvironment))
+	return pkgIntHelper.CommandError(err)
+}
+
+func (s *passStore) Get(ocmEnvironment string) (string, error) {
+	out, err := pkgIntHelper.RunCommandOutput("pass", "show", s.entry(ocmEnvironment))
+	if err != nil {
+		return "", fmt.Errorf("no token of OCM environment %s found in pass: %v", ocmEnvironment, pkgIntHelper.CommandError(err))
+	}
+	return strings.TrimSpace(strings.SplitN(string(out), "\n", 2)[0]), nil
+}
+
+func (s *passStore) Remove(ocmEnvironment string) error {
+	_, err := pkgIntHelper.RunCommandOutput("pass", "rm", "--force", s.entry(ocmEnvironment))
+	return pkgIntHelper.CommandError(err)
+}
+
+func (s *passStore) String() string {
+	return "pass password store"
+}
+
+// keyringSource reads the token of an OCM environment from a host keyring.
+type keyringSource struct {
+	store          Store
+	ocmEnvironment string
+}
+
+func (s *keyringSource) GetToken() (string, error) {
+	return s.store.Get(s.ocmEnvironment)
+}
+
+func (s *keyringSource) String() string {
+	return s.store.String()
+}
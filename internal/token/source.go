@@ -0,0 +1,170 @@
+/*
+Copyright © 2023 Jose Cueto
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package token
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"strings"
+
+	pkgIntHelper "ocm-workspace/internal/helpers"
+)
+
+// Types of token sources.
+const (
+	SourceFile    = "file"
+	SourceEnv     = "env"
+	SourceOcm     = "ocm"
+	SourceCommand = "command"
+	SourceKeyring = "keyring"
+)
+
+// SourceConfig configures where an OCM token is read from.
+type SourceConfig struct {
+	// One of file, env, ocm, command or keyring.
+	Type string `mapstructure:"type" yaml:"type,omitempty"`
+	// Token file path of the file source.
+	Path string `mapstructure:"path" yaml:"path,omitempty"`
+	// Environment variable name of the env source.
+	EnvVar string `mapstructure:"envVar" yaml:"envVar,omitempty"`
+	// Credential helper command (and args) of the command source. The token
+	// is read from its standard output.
+	Command []string `mapstructure:"command" yaml:"command,omitempty"`
+	// Host keyring backend of the keyring source (secret-service or pass).
+	Backend string `mapstructure:"backend" yaml:"backend,omitempty"`
+}
+
+// Source provides an OCM token.
+type Source interface {
+	GetToken() (string, error)
+	String() string
+}
+
+// Creates the token source of an OCM environment from its config.
+func NewSource(ocmEnvironment string, cfg SourceConfig) (Source, error) {
+	switch cfg.Type {
+	case SourceFile:
+		if len(cfg.Path) == 0 {
+			return nil, errors.New("file token source requires a path")
+		}
+		return &fileSource{path: cfg.Path}, nil
+	case SourceEnv:
+		if len(cfg.EnvVar) == 0 {
+			return nil, errors.New("env token source requires an envVar")
+		}
+		return &envSource{name: cfg.EnvVar}, nil
+	case SourceOcm, "":
+		return &ocmSource{}, nil
+	case SourceCommand:
+		if len(cfg.Command) == 0 {
+			return nil, errors.New("command token source requires a command")
+		}
+		return &commandSource{command: cfg.Command}, nil
+	case SourceKeyring:
+		store, err := NewStore(cfg.Backend)
+		if err != nil {
+			return nil, err
+		}
+		return &keyringSource{store: store, ocmEnvironment: ocmEnvironment}, nil
+	default:
+		return nil, fmt.Errorf(
+			"token source type %q is not supported (supported: %s, %s, %s, %s, %s)",
+			cfg.Type,
+			SourceFile,
+			SourceEnv,
+			SourceOcm,
+			SourceCommand,
+			SourceKeyring,
+		)
+	}
+}
+
+// fileSource reads the token from a file that only its owner can read.
+type fileSource struct {
+	path string
+}
+
+func (s *fileSource) GetToken() (string, error) {
+	info, err := os.Stat(s.path)
+	if err != nil {
+		return "", err
+	}
+	if info.Mode().Perm()&0077 != 0 {
+		return "", fmt.Errorf(
+			"token file %s is accessible by group or others (mode %#o), run chmod 600 %s",
+			s.path,
+			info.Mode().Perm(),
+			s.path,
+		)
+	}
+
+	content, err := os.ReadFile(s.path)
+	if err != nil {
+		return "", err
+	}
+	return strings.TrimSpace(string(content)), nil
+}
+
+func (s *fileSource) String() string {
+	return fmt.Sprintf("file %s", s.path)
+}
+
+// envSource reads the token from an environment variable.
+type envSource struct {
+	name string
+}
+
+func (s *envSource) GetToken() (string, error) {
+	value, ok := os.LookupEnv(s.name)
+	if !ok {
+		return "", fmt.Errorf("environment variable %s is not set", s.name)
+	}
+	return strings.TrimSpace(value), nil
+}
+
+func (s *envSource) String() string {
+	return fmt.Sprintf("environment variable %s", s.name)
+}
+
+// ocmSource gets the token of the host's OCM session (ocm token).
+type ocmSource struct{}
+
+func (s *ocmSource) GetToken() (string, error) {
+	return pkgIntHelper.OcmGetOCMToken()
+}
+
+func (s *ocmSource) String() string {
+	return "ocm token"
+}
+
+// commandSource gets the token from a credential helper command.
+type commandSource struct {
+	command []string
+}
+
+func (s *commandSource) GetToken() (string, error) {
+	out, err := pkgIntHelper.RunCommandOutput(s.command[0], s.command[1:]...)
+	if err != nil {
+		return "", pkgIntHelper.CommandError(err)
+	}
+	return strings.TrimSpace(string(out)), nil
+}
+
+func (s *commandSource) String() string {
+	return fmt.Sprintf("credential helper %s", s.command[0])
+}
@@ -0,0 +1,148 @@
+/*
+Copyright © 2023 Jose Cueto
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package token
+
+import (
+	"errors"
+	"fmt"
+	"net/url"
+	"strings"
+	"time"
+
+	pkgIntHelper "ocm-workspace/internal/helpers"
+)
+
+// Kinds of OCM tokens.
+const (
+	KindAccess  = "access"
+	KindRefresh = "refresh"
+	KindOffline = "offline"
+)
+
+// Values of the typ claim of each kind of token issued by Red Hat SSO.
+var kindsByTyp = map[string]string{
+	"bearer":  KindAccess,
+	"refresh": KindRefresh,
+	"offline": KindOffline,
+}
+
+// Claims are the OCM token claims checked before a token is used.
+type Claims struct {
+	Exp int64  `json:"exp"`
+	Iat int64  `json:"iat"`
+	Iss string `json:"iss"`
+	Typ string `json:"typ"`
+}
+
+// Token is a parsed (unverified) OCM token.
+type Token struct {
+	Raw    string
+	Claims Claims
+}
+
+// ValidateOptions configures the token validation.
+type ValidateOptions struct {
+	// Accepted issuers, any https issuer is accepted if empty.
+	Issuers []string
+	// Accepted kinds of tokens, all kinds are accepted if empty.
+	Kinds []string
+}
+
+// Parses an OCM token (JWT) without verifying its signature.
+func Parse(rawToken string) (*Token, error) {
+	rawToken = strings.TrimSpace(rawToken)
+	if len(rawToken) == 0 {
+		return nil, errors.New("token is empty, run ocm login")
+	}
+
+	t := &Token{Raw: rawToken}
+	err := decodeClaims(rawToken, &t.Claims)
+	if err != nil {
+		return nil, fmt.Errorf("%v, expected an OCM token (JWT)", err)
+	}
+	return t, nil
+}
+
+// Gets the kind of the token (access, refresh or offline).
+func (t *Token) Kind() string {
+	return kindsByTyp[strings.ToLower(t.Claims.Typ)]
+}
+
+// Gets the token expiry, zero if the token does not expire.
+func (t *Token) ExpiresAt() time.Time {
+	if t.Claims.Exp == 0 {
+		return time.Time{}
+	}
+	return time.Unix(t.Claims.Exp, 0)
+}
+
+// Validates the token expiry, issuer and type.
+func (t *Token) Validate(opts ValidateOptions) error {
+	kind := t.Kind()
+	if len(kind) == 0 {
+		return fmt.Errorf("token type %q is not an OCM access, refresh or offline token", t.Claims.Typ)
+	}
+	if len(opts.Kinds) > 0 && !contains(opts.Kinds, kind) {
+		return fmt.Errorf("%s tokens are not accepted here, expected a %s token", kind, strings.Join(opts.Kinds, " or "))
+	}
+
+	expiresAt := t.ExpiresAt()
+	if kind == KindAccess && expiresAt.IsZero() {
+		return errors.New("access token has no expiry (exp claim)")
+	}
+	if !expiresAt.IsZero() && time.Now().After(expiresAt) {
+		return fmt.Errorf(
+			"%s token expired %s ago, run ocm login",
+			kind,
+			pkgIntHelper.FormatDuration(time.Since(expiresAt)),
+		)
+	}
+
+	if len(opts.Issuers) > 0 {
+		if !contains(opts.Issuers, t.Claims.Iss) {
+			return fmt.Errorf(
+				"token was issued by %q, expected %s; run ocm login for the right OCM environment",
+				t.Claims.Iss,
+				strings.Join(opts.Issuers, " or "),
+			)
+		}
+	} else {
+		issuer, err := url.Parse(t.Claims.Iss)
+		if err != nil || issuer.Scheme != "https" || len(issuer.Host) == 0 {
+			return fmt.Errorf("token issuer %q is not an https URL", t.Claims.Iss)
+		}
+	}
+	return nil
+}
+
+// Parses and validates an OCM token.
+func ParseAndValidate(rawToken string, opts ValidateOptions) (*Token, error) {
+	t, err := Parse(rawToken)
+	if err != nil {
+		return nil, err
+	}
+	return t, t.Validate(opts)
+}
+
+func contains(values []string, value string) bool {
+	for _, v := range values {
+		if v == value {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,183 @@
+/*
+Copyright © 2023 Jose Cueto
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package token
+
+import (
+	"encoding/base64"
+	"encoding/json"
+	"strings"
+	"testing"
+	"time"
+)
+
+const testIssuer = "https://sso.redhat.com/auth/realms/redhat-external"
+
+// Builds an unsigned JWT with the given claims.
+func newTestToken(t *testing.T, claims map[string]interface{}) string {
+	t.Helper()
+	payload, err := json.Marshal(claims)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return strings.Join([]string{
+		base64.RawURLEncoding.EncodeToString([]byte(`{"alg":"none"}`)),
+		base64.RawURLEncoding.EncodeToString(payload),
+		"c2lnbmF0dXJl",
+	}, ".")
+}
+
+func TestParse(t *testing.T) {
+	tests := []struct {
+		name     string
+		rawToken string
+		wantErr  string
+		wantKind string
+	}{
+		{name: "empty", rawToken: "  ", wantErr: "token is empty"},
+		{name: "not a JWT", rawToken: "abc.def", wantErr: "token is not a JWT"},
+		{name: "invalid payload", rawToken: "a.!!!.c", wantErr: "failed to decode token payload"},
+		{
+			name:     "invalid claims",
+			rawToken: "a." + base64.RawURLEncoding.EncodeToString([]byte(`[]`)) + ".c",
+			wantErr:  "failed to unmarshal token claims",
+		},
+		{
+			name:     "offline token",
+			rawToken: newTestToken(t, map[string]interface{}{"typ": "Offline", "iss": testIssuer}),
+			wantKind: KindOffline,
+		},
+		{
+			name:     "surrounding whitespace",
+			rawToken: " " + newTestToken(t, map[string]interface{}{"typ": "Bearer", "exp": 1}) + "\n",
+			wantKind: KindAccess,
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			token, err := Parse(test.rawToken)
+			if len(test.wantErr) > 0 {
+				if err == nil || !strings.Contains(err.Error(), test.wantErr) {
+					t.Fatalf("expected error %q, got %v", test.wantErr, err)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatal(err)
+			}
+			if token.Kind() != test.wantKind {
+				t.Fatalf("expected kind %q, got %q", test.wantKind, token.Kind())
+			}
+			if token.Raw != strings.TrimSpace(test.rawToken) {
+				t.Fatalf("expected the raw token to be trimmed, got %q", token.Raw)
+			}
+		})
+	}
+}
+
+func TestValidate(t *testing.T) {
+	future := time.Now().Add(time.Hour).Unix()
+	past := time.Now().Add(-time.Hour).Unix()
+
+	tests := []struct {
+		name    string
+		claims  map[string]interface{}
+		opts    ValidateOptions
+		wantErr string
+	}{
+		{
+			name:   "valid access token",
+			claims: map[string]interface{}{"typ": "Bearer", "exp": future, "iss": testIssuer},
+		},
+		{
+			name:   "offline token without expiry",
+			claims: map[string]interface{}{"typ": "Offline", "iss": testIssuer},
+		},
+		{
+			name:    "expired access token",
+			claims:  map[string]interface{}{"typ": "Bearer", "exp": past, "iss": testIssuer},
+			wantErr: "access token expired",
+		},
+		{
+			name:    "expired refresh token",
+			claims:  map[string]interface{}{"typ": "Refresh", "exp": past, "iss": testIssuer},
+			wantErr: "refresh token expired",
+		},
+		{
+			name:    "access token without expiry",
+			claims:  map[string]interface{}{"typ": "Bearer", "iss": testIssuer},
+			wantErr: "access token has no expiry",
+		},
+		{
+			name:    "unknown typ",
+			claims:  map[string]interface{}{"typ": "ID", "exp": future, "iss": testIssuer},
+			wantErr: `token type "ID" is not an OCM`,
+		},
+		{
+			name:    "missing typ",
+			claims:  map[string]interface{}{"exp": future, "iss": testIssuer},
+			wantErr: `token type "" is not an OCM`,
+		},
+		{
+			name:    "kind not accepted",
+			claims:  map[string]interface{}{"typ": "Bearer", "exp": future, "iss": testIssuer},
+			opts:    ValidateOptions{Kinds: []string{KindOffline, KindRefresh}},
+			wantErr: "access tokens are not accepted here",
+		},
+		{
+			name:   "kind accepted",
+			claims: map[string]interface{}{"typ": "Refresh", "exp": future, "iss": testIssuer},
+			opts:   ValidateOptions{Kinds: []string{KindOffline, KindRefresh}},
+		},
+		{
+			name:   "expected issuer",
+			claims: map[string]interface{}{"typ": "Offline", "iss": testIssuer},
+			opts:   ValidateOptions{Issuers: []string{testIssuer}},
+		},
+		{
+			name:    "other issuer",
+			claims:  map[string]interface{}{"typ": "Offline", "iss": "https://sso.stage.redhat.com/auth/realms/redhat-external"},
+			opts:    ValidateOptions{Issuers: []string{testIssuer}},
+			wantErr: "token was issued by",
+		},
+		{
+			name:    "http issuer",
+			claims:  map[string]interface{}{"typ": "Offline", "iss": "http://sso.example.com"},
+			wantErr: "is not an https URL",
+		},
+		{
+			name:    "missing issuer",
+			claims:  map[string]interface{}{"typ": "Offline"},
+			wantErr: "is not an https URL",
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			_, err := ParseAndValidate(newTestToken(t, test.claims), test.opts)
+			if len(test.wantErr) == 0 {
+				if err != nil {
+					t.Fatal(err)
+				}
+				return
+			}
+			if err == nil || !strings.Contains(err.Error(), test.wantErr) {
+				t.Fatalf("expected error %q, got %v", test.wantErr, err)
+			}
+		})
+	}
+}
@@ -0,0 +1,279 @@
+/*
+Copyright © 2023 Jose Cueto
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package internal
+
+import (
+	"fmt"
+	"net/url"
+	"os"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+
+	pkgIntToken "ocm-workspace/internal/token"
+)
+
+// VolMapAttrs are the supported volume map attributes (fileAttrs).
+var VolMapAttrs = []string{
+	"ro", "rw", "z", "Z", "U", "O",
+	"shared", "rshared", "slave", "rslave", "private", "rprivate",
+	"nocopy", "copy", "exec", "noexec", "suid", "nosuid", "dev", "nodev",
+}
+
+// ValidationError is a config problem at a YAML path (e.g. plugins[0].runOn).
+type ValidationError struct {
+	Path    string
+	Message string
+}
+
+func (e ValidationError) Error() string {
+	return fmt.Sprintf("%s: %s", e.Path, e.Message)
+}
+
+// ValidationErrors holds all of the problems found in a config.
+type ValidationErrors []ValidationError
+
+func (e ValidationErrors) Error() string {
+	messages := []string{}
+	for _, err := range e {
+		messages = append(messages, err.Error())
+	}
+	return strings.Join(messages, "\n")
+}
+
+type configValidator struct {
+	errs ValidationErrors
+}
+
+func (v *configValidator) addf(path string, format string, args ...interface{}) {
+	v.errs = append(v.errs, ValidationError{Path: path, Message: fmt.Sprintf(format, args...)})
+}
+
+func (v *configValidator) required(path string, value string) bool {
+	if len(value) == 0 {
+		v.addf(path, "is required")
+		return false
+	}
+	return true
+}
+
+func (v *configValidator) fileExists(path string, file string) {
+	if _, err := os.Stat(file); err != nil {
+		v.addf(path, "%s does not exist", file)
+	}
+}
+
+func (v *configValidator) port(path string, port string) {
+	value, err := strconv.Atoi(port)
+	if err != nil || value < 1 || value > 65535 {
+		v.addf(path, "%q is not a port number (1-65535)", port)
+	}
+}
+
+func (v *configValidator) oneOf(path string, value string, allowed []string) {
+	if !contains(allowed, value) {
+		v.addf(path, "%q is not one of %s", value, strings.Join(allowed, ", "))
+	}
+}
+
+// Validates the config against the host. All of the problems are returned
+// with their YAML paths, nil if the config is valid.
+func (c *OcmWorkspaceConfig) Validate() ValidationErrors {
+	v := &configValidator{}
+
+	v.required("hostUser", c.HostUser)
+	v.required("ocUser", c.OcUser)
+	if v.required("userHome", c.UserHome) {
+		if info, err := os.Stat(c.UserHome); err != nil || !info.IsDir() {
+			v.addf("userHome", "%s is not a directory", c.UserHome)
+		}
+	}
+
+	for _, backplaneConfig := range [][]string{
+		{"backplaneConfigProd", c.BackplaneConfigProd},
+		{"backplaneConfigStage", c.BackplaneConfigStage},
+	} {
+		if len(backplaneConfig[1]) > 0 {
+			v.fileExists(backplaneConfig[0], filepath.Join(c.UserHome, ".config", "backplane", backplaneConfig[1]))
+		}
+	}
+
+	if len(c.OcmLongLivedTokenPath) > 0 {
+		v.fileExists("ocmLongLivedTokenPath", c.OcmLongLivedTokenPath)
+	}
+
+	v.oneOf("containerEngine", c.GetContainerEngine(), supportedContainerEngines)
+
+	validateDirMaps(v, "customDirMaps", c.CustomDirMaps)
+	validatePATHDirs(v, "addToPATHEnv", c.AddToPATHEnv)
+
+	for idx, pm := range c.CustomPortMaps {
+		path := fmt.Sprintf("customPortMaps[%d]", idx)
+		if v.required(path+".containerPort", pm.ContainerPort) {
+			v.port(path+".containerPort", pm.ContainerPort)
+		}
+		if len(pm.HostPort) > 0 {
+			v.port(path+".hostPort", pm.HostPort)
+		}
+	}
+
+	validatePlugins(v, "plugins", c.Plugins)
+
+	for _, key := range sortedKeys(c.Clusters) {
+		path := fmt.Sprintf("clusters.%s", key)
+		if _, err := filepath.Match(key, ""); err != nil {
+			v.addf(path, "invalid cluster glob %q: %v", key, err)
+		}
+		cluster := c.Clusters[key]
+		validateDirMaps(v, path+".customDirMaps", cluster.CustomDirMaps)
+		validatePATHDirs(v, path+".addToPATHEnv", cluster.AddToPATHEnv)
+		validatePlugins(v, path+".plugins", cluster.Plugins)
+	}
+
+	validateProxy(v, "proxy", c.Proxy.ProxyConfig)
+	for _, name := range sortedKeys(c.Proxy.Environments) {
+		validateProxy(v, fmt.Sprintf("proxy.environments.%s", name), c.Proxy.Environments[name])
+	}
+
+	for _, name := range sortedKeys(c.TokenSources) {
+		validateTokenSource(v, fmt.Sprintf("tokenSources.%s", name), name, c.TokenSources[name])
+	}
+
+	for _, name := range sortedKeys(c.OcmEnvironments) {
+		env := c.OcmEnvironments[name]
+		path := fmt.Sprintf("ocmEnvironments.%s", name)
+		if _, err := c.ResolveOcmEnvironment(name); err != nil {
+			v.addf(path, "%v", err)
+		}
+		if env.TokenSource != nil {
+			validateTokenSource(v, path+".tokenSource", name, *env.TokenSource)
+		}
+		if env.Proxy != nil {
+			validateProxy(v, path+".proxy", *env.Proxy)
+		}
+		if len(env.BackplaneConfig) > 0 {
+			v.fileExists(path+".backplaneConfig", filepath.Join(c.UserHome, ".config", "backplane", env.BackplaneConfig))
+		}
+	}
+
+	for _, name := range sortedKeys(c.Profiles) {
+		if extends, ok := c.Profiles[name][ProfileExtendsKey]; ok {
+			if _, ok := c.Profiles[strings.ToLower(fmt.Sprint(extends))]; !ok {
+				v.addf(fmt.Sprintf("profiles.%s.%s", name, ProfileExtendsKey), "profile %v not found", extends)
+			}
+		}
+	}
+
+	return v.errs
+}
+
+func validateDirMaps(v *configValidator, path string, dirMaps []DirMap) {
+	for idx, dirMap := range dirMaps {
+		itemPath := fmt.Sprintf("%s[%d]", path, idx)
+		if v.required(itemPath+".hostDir", dirMap.HostDir) {
+			v.fileExists(itemPath+".hostDir", dirMap.HostDir)
+		}
+		if v.required(itemPath+".containerDir", dirMap.ContainerDir) && !filepath.IsAbs(dirMap.ContainerDir) {
+			v.addf(itemPath+".containerDir", "%s is not an absolute path", dirMap.ContainerDir)
+		}
+		if len(dirMap.FileAttrs) > 0 {
+			for _, attr := range strings.Split(dirMap.FileAttrs, ",") {
+				v.oneOf(itemPath+".fileAttrs", strings.TrimSpace(attr), VolMapAttrs)
+			}
+		}
+	}
+}
+
+func validatePATHDirs(v *configValidator, path string, dirs []string) {
+	for idx, dir := range dirs {
+		if !filepath.IsAbs(dir) {
+			v.addf(fmt.Sprintf("%s[%d]", path, idx), "%s is not an absolute path", dir)
+		}
+	}
+}
+
+func validatePlugins(v *configValidator, path string, plugins []Plugin) {
+	pluginNames := map[string]bool{}
+	for idx, plug := range plugins {
+		itemPath := fmt.Sprintf("%s[%d]", path, idx)
+		if v.required(itemPath+".name", plug.Name) {
+			if pluginNames[plug.Name] {
+				v.addf(itemPath+".name", "duplicate plugin name %s", plug.Name)
+			}
+			pluginNames[plug.Name] = true
+		}
+		if len(plug.ExecPath) > 0 {
+			v.fileExists(itemPath+".execPath", plug.ExecPath)
+		} else if _, err := GetInstalledPlugin(plug.Name); err != nil {
+			v.addf(itemPath+".execPath", "is required, %v", err)
+		}
+		if v.required(itemPath+".runOn", plug.RunOn) {
+			v.oneOf(itemPath+".runOn", plug.RunOn, PluginRunOnValues)
+		}
+		v.required(itemPath+".execCommand", plug.ExecCommand)
+		if len(plug.RestartPolicy) > 0 {
+			v.oneOf(itemPath+".restartPolicy", plug.RestartPolicy, PluginRestartPolicies)
+		}
+		if plug.AllocatePorts < 0 {
+			v.addf(itemPath+".allocatePorts", "must not be negative")
+		}
+		if plug.manifest != nil {
+			validatePluginManifest(v, itemPath, plug)
+		}
+	}
+}
+
+func validateProxy(v *configValidator, path string, proxy ProxyConfig) {
+	for _, proxyUrl := range [][]string{
+		{"httpProxy", proxy.HttpProxy},
+		{"httpsProxy", proxy.HttpsProxy},
+	} {
+		if len(proxyUrl[1]) == 0 {
+			continue
+		}
+		parsed, err := url.Parse(proxyUrl[1])
+		if err != nil || len(parsed.Scheme) == 0 || len(parsed.Host) == 0 {
+			v.addf(path+"."+proxyUrl[0], "%q is not a proxy URL (e.g. http://proxy.example.com:3128)", proxyUrl[1])
+		}
+	}
+}
+
+func validateTokenSource(v *configValidator, path string, ocmEnvironment string, source pkgIntToken.SourceConfig) {
+	if _, err := pkgIntToken.NewSource(ocmEnvironment, source); err != nil {
+		v.addf(path, "%v", err)
+	}
+}
+
+// Gets the sorted keys of a config map for a stable error order.
+func sortedKeys[V any](values map[string]V) []string {
+	keys := []string{}
+	for key := range values {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	return keys
+}
+
+func contains(values []string, value string) bool {
+	for _, v := range values {
+		if v == value {
+			return true
+		}
+	}
+	return false
+}
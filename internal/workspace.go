@@ -0,0 +1,307 @@
+/*
+Copyright © 2023 Jose Cueto
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+package internal
+
+import (
+	"encoding/json"
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+
+	pkgIntHelper "ocm-workspace/internal/helpers"
+)
+
+// Labels set on the workspace containers at login time.
+const (
+	WorkspaceLabel               = "ocm-workspace"
+	WorkspaceClusterLabel        = "ocm-workspace.cluster"
+	WorkspaceEnvironmentLabel    = "ocm-workspace.environment"
+	WorkspaceConsolePortLabel    = "ocm-workspace.console-port"
+	WorkspaceCustomPortMapsLabel = "ocm-workspace.custom-port-maps"
+	WorkspacePluginPortsLabel    = "ocm-workspace.plugin-ports"
+	WorkspaceHostUserLabel       = "ocm-workspace.host-user"
+)
+
+// Labels set on the OpenShift console containers.
+const (
+	ConsoleWorkspaceLabel = "ocm-workspace.console-of"
+	ConsoleUrlLabel       = "ocm-workspace.console-url"
+)
+
+// Gets the name of the OpenShift console container of a workspace.
+func GetConsoleContainerName(workspaceName string) string {
+	return fmt.Sprintf("%s-openshift-console", workspaceName)
+}
+
+// Workspace is a workspace container created by the login command.
+type Workspace struct {
+	ID             string               `json:"id" yaml:"id"`
+	Name           string               `json:"name" yaml:"name"`
+	Cluster        string               `json:"cluster" yaml:"cluster"`
+	OcmEnvironment string               `json:"ocmEnvironment" yaml:"ocmEnvironment"`
+	HostUser       string               `json:"hostUser" yaml:"hostUser"`
+	Status         string               `json:"status" yaml:"status"`
+	Running        bool                 `json:"running" yaml:"running"`
+	Created        time.Time            `json:"created" yaml:"created"`
+	ConsolePort    string               `json:"consolePort" yaml:"consolePort"`
+	CustomPortMaps []PortMap            `json:"customPortMaps" yaml:"customPortMaps"`
+	PluginPorts    map[string][]PortMap `json:"pluginPorts" yaml:"pluginPorts"`
+}
+
+type containerInspectState struct {
+	Status  string `json:"Status"`
+	Running bool   `json:"Running"`
+}
+
+type containerInspectConfig struct {
+	Image  string            `json:"Image"`
+	Env    []string          `json:"Env"`
+	Labels map[string]string `json:"Labels"`
+}
+
+type containerInspect struct {
+	ID      string                 `json:"Id"`
+	Name    string                 `json:"Name"`
+	Created time.Time              `json:"Created"`
+	State   containerInspectState  `json:"State"`
+	Config  containerInspectConfig `json:"Config"`
+}
+
+// Gets the value of an environment variable of an inspected container.
+func (ci *containerInspect) getEnvVar(name string) string {
+	for _, env := range ci.Config.Env {
+		kv := strings.SplitN(env, "=", 2)
+		if len(kv) == 2 && kv[0] == name {
+			return kv[1]
+		}
+	}
+	return ""
+}
+
+func (ci *containerInspect) toWorkspace() Workspace {
+	labels := ci.Config.Labels
+	consolePort := labels[WorkspaceConsolePortLabel]
+	if len(consolePort) == 0 {
+		consolePort = ci.getEnvVar("OPENSHIFT_CONSOLE_PORT")
+	}
+
+	return Workspace{
+		ID:             ci.ID,
+		Name:           strings.TrimPrefix(ci.Name, "/"),
+		Cluster:        labels[WorkspaceClusterLabel],
+		OcmEnvironment: labels[WorkspaceEnvironmentLabel],
+		HostUser:       labels[WorkspaceHostUserLabel],
+		Status:         ci.State.Status,
+		Running:        ci.State.Running,
+		Created:        ci.Created,
+		ConsolePort:    consolePort,
+		CustomPortMaps: ParsePortMaps(labels[WorkspaceCustomPortMapsLabel]),
+		PluginPorts:    ParsePluginPorts(labels[WorkspacePluginPortsLabel]),
+	}
+}
+
+func inspectContainers(ce ContainerEngine, containers ...string) ([]containerInspect, error) {
+	out, err := pkgIntHelper.RunCommandOutput(ce.GetExecName(), ce.GetInspectArgs(containers...)...)
+	if err != nil {
+		return nil, fmt.Errorf("failed to inspect containers %v: %v", containers, err)
+	}
+
+	var inspected []containerInspect
+	err = json.Unmarshal(out, &inspected)
+	if err != nil {
+		return nil, fmt.Errorf("failed to unmarshal container inspect output: %v", err)
+	}
+	return inspected, nil
+}
+
+// Lists the workspace containers, including the stopped ones if all is true.
+func ListWorkspaces(ce ContainerEngine, all bool) ([]Workspace, error) {
+	out, err := pkgIntHelper.RunCommandOutput(ce.GetExecName(), ce.GetPsArgs(all, WorkspaceLabel)...)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list workspace containers: %v", err)
+	}
+
+	ids := strings.Fields(string(out))
+	if len(ids) == 0 {
+		return []Workspace{}, nil
+	}
+
+	inspected, err := inspectContainers(ce, ids...)
+	if err != nil {
+		return nil, err
+	}
+
+	workspaces := []Workspace{}
+	for _, ci := range inspected {
+		workspaces = append(workspaces, ci.toWorkspace())
+	}
+	sort.Slice(workspaces, func(i, j int) bool {
+		return workspaces[i].Created.Before(workspaces[j].Created)
+	})
+	return workspaces, nil
+}
+
+// Console is an OpenShift console container attached to a workspace.
+type Console struct {
+	ID        string    `json:"id" yaml:"id"`
+	Name      string    `json:"name" yaml:"name"`
+	Workspace string    `json:"workspace" yaml:"workspace"`
+	Url       string    `json:"url" yaml:"url"`
+	Image     string    `json:"image" yaml:"image"`
+	Status    string    `json:"status" yaml:"status"`
+	Running   bool      `json:"running" yaml:"running"`
+	Created   time.Time `json:"created" yaml:"created"`
+}
+
+// Lists the running OpenShift console containers.
+func ListConsoles(ce ContainerEngine) ([]Console, error) {
+	out, err := pkgIntHelper.RunCommandOutput(ce.GetExecName(), ce.GetPsArgs(false, ConsoleWorkspaceLabel)...)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list console containers: %v", err)
+	}
+
+	ids := strings.Fields(string(out))
+	if len(ids) == 0 {
+		return []Console{}, nil
+	}
+
+	inspected, err := inspectContainers(ce, ids...)
+	if err != nil {
+		return nil, err
+	}
+
+	consoles := []Console{}
+	for _, ci := range inspected {
+		consoles = append(consoles, Console{
+			ID:        ci.ID,
+			Name:      strings.TrimPrefix(ci.Name, "/"),
+			Workspace: ci.Config.Labels[ConsoleWorkspaceLabel],
+			Url:       ci.Config.Labels[ConsoleUrlLabel],
+			Image:     ci.Config.Image,
+			Status:    ci.State.Status,
+			Running:   ci.State.Running,
+			Created:   ci.Created,
+		})
+	}
+	return consoles, nil
+}
+
+// Finds the running OpenShift console of a workspace.
+func FindConsole(consoles []Console, workspaceName string) *Console {
+	for idx, console := range consoles {
+		if console.Workspace == workspaceName {
+			return &consoles[idx]
+		}
+	}
+	return nil
+}
+
+// Finds a workspace by its container name, container ID prefix or cluster. If
+// ref is empty, the only given workspace is returned.
+func FindWorkspace(workspaces []Workspace, ref string) (*Workspace, error) {
+	if len(ref) == 0 {
+		switch len(workspaces) {
+		case 0:
+			return nil, fmt.Errorf("no workspace found")
+		case 1:
+			return &workspaces[0], nil
+		default:
+			return nil, fmt.Errorf("found %d workspaces, specify one of: %s", len(workspaces), workspaceNames(workspaces))
+		}
+	}
+
+	for idx, ws := range workspaces {
+		if ws.Name == ref || strings.HasPrefix(ws.ID, ref) {
+			return &workspaces[idx], nil
+		}
+	}
+
+	matches := []Workspace{}
+	for _, ws := range workspaces {
+		if ws.Cluster == ref {
+			matches = append(matches, ws)
+		}
+	}
+
+	switch len(matches) {
+	case 0:
+		return nil, fmt.Errorf("no workspace found for %s", ref)
+	case 1:
+		return &matches[0], nil
+	default:
+		return nil, fmt.Errorf("found %d workspaces for cluster %s, specify one of: %s", len(matches), ref, workspaceNames(matches))
+	}
+}
+
+func workspaceNames(workspaces []Workspace) string {
+	names := []string{}
+	for _, ws := range workspaces {
+		names = append(names, ws.Name)
+	}
+	return strings.Join(names, ", ")
+}
+
+// Formats port maps as a comma separated list of hostPort:containerPort.
+func FormatPortMaps(portMaps []PortMap) string {
+	pms := []string{}
+	for _, pm := range portMaps {
+		pms = append(pms, fmt.Sprintf("%s:%s", pm.HostPort, pm.ContainerPort))
+	}
+	return strings.Join(pms, ",")
+}
+
+// Parses a comma separated list of hostPort:containerPort.
+func ParsePortMaps(value string) []PortMap {
+	portMaps := []PortMap{}
+	for _, pm := range strings.Split(strings.Trim(value, ","), ",") {
+		ports := strings.Split(pm, ":")
+		if len(ports) != 2 {
+			continue
+		}
+		portMaps = append(portMaps, PortMap{HostPort: ports[0], ContainerPort: ports[1]})
+	}
+	return portMaps
+}
+
+// Formats plugin port maps as a semicolon separated list of
+// pluginName=hostPort:containerPort,...
+func FormatPluginPorts(pluginPorts map[string][]PortMap) string {
+	names := []string{}
+	for name := range pluginPorts {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	entries := []string{}
+	for _, name := range names {
+		entries = append(entries, fmt.Sprintf("%s=%s", name, FormatPortMaps(pluginPorts[name])))
+	}
+	return strings.Join(entries, ";")
+}
+
+// Parses the output of FormatPluginPorts.
+func ParsePluginPorts(value string) map[string][]PortMap {
+	pluginPorts := map[string][]PortMap{}
+	for _, entry := range strings.Split(value, ";") {
+		kv := strings.SplitN(entry, "=", 2)
+		if len(kv) != 2 {
+			continue
+		}
+		pluginPorts[kv[0]] = ParsePortMaps(kv[1])
+	}
+	return pluginPorts
+}